@@ -0,0 +1,133 @@
+package xt
+
+import "fmt"
+
+/*
+Applies the changes in order, such as those produced by `Diff`. Like in
+`Diff`, the path of each change refers to the tree as modified by the
+preceding changes, so an earlier removal shifts the indexes of later changes.
+For example, `Diff(a, b)` applied to `a` produces nodes equal to `b`:
+
+	changes := Diff(a, b)
+	err := a.Apply(changes)
+
+When `Old` is set for `ChangeRemove`, `ChangeReplace` or `ChangeAttrs`, it must
+be equal to the current node, or for `ChangeAttrs` have equal attributes,
+which detects changes applied to a different tree. Returns an error if a
+change doesn't apply, in which case the nodes are left unchanged.
+
+The changes are applied to copies of the modified elements and `Nodes` slices,
+along the path to each change. Other nodes, including other descendants of
+modified elements, are shared with the original. Elements stored as `*Elem`
+are replaced with pointers to modified copies, rather than modified in-place.
+*/
+func (self *Nodes) Apply(changes []Change) error {
+	out := *self
+	for ind, change := range changes {
+		var err error
+		out, err = applyChange(out, change.Path, change)
+		if err != nil {
+			return fmt.Errorf(`can't apply change %d (%s at %q): %w`, ind, change.Kind, change.Path, err)
+		}
+	}
+	*self = out
+	return nil
+}
+
+func applyChange(nodes Nodes, path Path, change Change) (Nodes, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf(`empty path`)
+	}
+
+	ind := path[0]
+	max := len(nodes)
+	if len(path) == 1 && change.Kind == ChangeInsert {
+		max++
+	}
+	if ind < 0 || ind >= max {
+		return nil, fmt.Errorf(`index %d out of range for %d nodes`, ind, len(nodes))
+	}
+
+	if len(path) > 1 {
+		elem, ok := nodeElem(nodes[ind])
+		if !ok {
+			return nil, fmt.Errorf(`expected element at index %d, found %T`, ind, nodes[ind])
+		}
+		inner, err := applyChange(elem.Nodes, path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		out := *elem
+		out.Nodes = inner
+		return replaceNode(nodes, ind, out), nil
+	}
+
+	switch change.Kind {
+	case ChangeInsert:
+		out := make(Nodes, 0, len(nodes)+1)
+		out = append(out, nodes[:ind]...)
+		out = append(out, change.New)
+		return append(out, nodes[ind:]...), nil
+
+	case ChangeRemove:
+		err := applyCheckOld(nodes[ind], change.Old)
+		if err != nil {
+			return nil, err
+		}
+		out := make(Nodes, 0, len(nodes)-1)
+		out = append(out, nodes[:ind]...)
+		return append(out, nodes[ind+1:]...), nil
+
+	case ChangeReplace:
+		err := applyCheckOld(nodes[ind], change.Old)
+		if err != nil {
+			return nil, err
+		}
+		out := append(Nodes(nil), nodes...)
+		out[ind] = change.New
+		return out, nil
+
+	case ChangeAttrs:
+		elem, ok := nodeElem(nodes[ind])
+		if !ok {
+			return nil, fmt.Errorf(`expected element at index %d, found %T`, ind, nodes[ind])
+		}
+		next, ok := nodeElem(change.New)
+		if !ok {
+			return nil, fmt.Errorf(`expected element in new value, found %T`, change.New)
+		}
+		if change.Old != nil {
+			prev, ok := nodeElem(change.Old)
+			if !ok || !(EqualOptions{RespectPrefixes: true}).attrs(elem.Attrs, prev.Attrs) {
+				return nil, fmt.Errorf(`attributes of element at index %d don't match the old value`, ind)
+			}
+		}
+		out := *elem
+		out.Attrs = next.Attrs
+		out.RawAttrs = nil
+		return replaceNode(nodes, ind, out), nil
+	}
+
+	return nil, fmt.Errorf(`invalid change kind %d`, change.Kind)
+}
+
+func applyCheckOld(node, old Node) error {
+	if old != nil && !(EqualOptions{RespectPrefixes: true}).node(node, old) {
+		return fmt.Errorf(`node doesn't match the old value`)
+	}
+	return nil
+}
+
+/*
+Returns a copy of the nodes with the element at the given index replaced,
+preserving the distinction between `Elem` and `*Elem`.
+*/
+func replaceNode(nodes Nodes, ind int, elem Elem) Nodes {
+	out := append(Nodes(nil), nodes...)
+	if _, ok := nodes[ind].(*Elem); ok {
+		out[ind] = &elem
+	} else {
+		out[ind] = elem
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	a := Nodes{
+		Elem{
+			Name:  Name{Local: `config`},
+			Attrs: []Attr{{Name{Local: `version`}, `1`}},
+			Nodes: Nodes{E(`a`), E(`b`).C(Text(`one`)), Comment(`note`)},
+		},
+	}
+	b := Nodes{
+		E(`config`).A(`version`, `2`).C(E(`b`).C(Text(`two`)), Comment(`note`), E(`c`)),
+	}
+
+	orig := a.Clone()
+	out := a
+	require.NoError(t, out.Apply(Diff(a, b)))
+	require.True(t, Equal(b, out, EqualOptions{}))
+	require.Nil(t, Diff(out, b))
+	require.Equal(t, orig, a)
+	require.IsType(t, Elem{}, out[0])
+
+	out = Nodes{E(`a`), E(`b`), E(`c`)}
+	require.NoError(t, out.Apply([]Change{
+		{Kind: ChangeRemove, Path: Path{0}},
+		{Kind: ChangeRemove, Path: Path{1}},
+		{Kind: ChangeInsert, Path: Path{1}, New: Text(`d`)},
+	}))
+	require.Equal(t, Nodes{E(`b`), Text(`d`)}, out)
+}
+
+func TestApplyInvalid(t *testing.T) {
+	nodes := Nodes{E(`a`).C(Text(`one`))}
+	orig := nodes.Clone()
+
+	require.EqualError(t, nodes.Apply([]Change{
+		{Kind: ChangeInsert, Path: Path{0, 1}, New: Text(`two`)},
+		{Kind: ChangeRemove, Path: Path{0, 3}},
+	}), `can't apply change 1 (remove at "nodes[0].nodes[3]"): index 3 out of range for 2 nodes`)
+	require.Equal(t, orig, nodes)
+
+	require.EqualError(t, nodes.Apply([]Change{
+		{Kind: ChangeReplace, Path: Path{0, 0}, Old: Text(`two`), New: Text(`three`)},
+	}), `can't apply change 0 (replace at "nodes[0].nodes[0]"): node doesn't match the old value`)
+
+	require.EqualError(t, nodes.Apply([]Change{
+		{Kind: ChangeAttrs, Path: Path{0, 0}, New: E(`a`)},
+	}), `can't apply change 0 (attrs at "nodes[0].nodes[0]"): expected element at index 0, found xt.Text`)
+
+	require.EqualError(t, nodes.Apply([]Change{
+		{Kind: ChangeRemove, Path: Path{0, 0, 0}},
+	}), `can't apply change 0 (remove at "nodes[0].nodes[0].nodes[0]"): expected element at index 0, found xt.Text`)
+
+	require.EqualError(t, nodes.Apply([]Change{{Path: Path{0}}}), `can't apply change 0 (invalid at "nodes[0]"): invalid change kind 0`)
+	require.EqualError(t, nodes.Apply([]Change{{Kind: ChangeRemove}}), `can't apply change 0 (remove at ""): empty path`)
+	require.Equal(t, orig, nodes)
+}
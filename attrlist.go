@@ -0,0 +1,83 @@
+package xt
+
+/*
+Map-like accessors layered on top of an attribute slice, which remains the
+underlying storage, preserving order and duplicates. `Elem.Attrs` can be used
+as `AttrList` via a conversion, without copying:
+
+	AttrList(elem.Attrs).Get("", "id")
+	(*AttrList)(&elem.Attrs).Set("", "id", "one")
+
+In all methods, empty `space` denotes an attribute without a namespace, and
+only the first attribute with the given name is considered. Also see
+`Elem.Attr`, `Elem.SetAttr` and `Elem.RemoveAttr`, which are shortcuts for
+these methods.
+*/
+type AttrList []Attr
+
+// Returns the value of the first matching attribute, and whether it was found.
+func (self AttrList) Get(space, local string) (string, bool) {
+	ind := self.index(Name{Space: space, Local: local})
+	if ind < 0 {
+		return "", false
+	}
+	return self[ind].Value, true
+}
+
+/*
+Sets the value of the first matching attribute, updating it in-place, or
+appends a new attribute if there is none.
+*/
+func (self *AttrList) Set(space, local, value string) {
+	name := Name{Space: space, Local: local}
+	ind := self.index(name)
+	if ind < 0 {
+		*self = append(*self, Attr{Name: name, Value: value})
+		return
+	}
+	(*self)[ind].Value = value
+}
+
+/*
+Removes the first matching attribute, keeping the order of the remaining
+attributes, and reports whether it was found. Doesn't modify the original
+backing array, which may be shared with copies of the list. Removing the last
+attribute leaves an empty non-nil slice.
+*/
+func (self *AttrList) Delete(space, local string) bool {
+	ind := self.index(Name{Space: space, Local: local})
+	if ind < 0 {
+		return false
+	}
+	*self = append((*self)[:ind:ind], (*self)[ind+1:]...)
+	return true
+}
+
+/*
+Returns the attributes as a map from names to values, for quick lookups when
+duplicates don't matter. For duplicate names, the first value wins, like in
+`AttrList.Get`. Namespace declarations are included under their names, such
+as `Name{Space: NamespaceXMLNS, Local: "one"}`. Returns nil for an empty
+list.
+*/
+func (self AttrList) Map() map[Name]string {
+	if len(self) == 0 {
+		return nil
+	}
+	out := make(map[Name]string, len(self))
+	for _, attr := range self {
+		if _, ok := out[attr.Name]; !ok {
+			out[attr.Name] = attr.Value
+		}
+	}
+	return out
+}
+
+func (self AttrList) index(name Name) int {
+	for ind, attr := range self {
+		if attr.Name == name {
+			return ind
+		}
+	}
+	return -1
+}
@@ -0,0 +1,54 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttrList(t *testing.T) {
+	elem := Elem{
+		Name: Name{Local: `one`},
+		Attrs: []Attr{
+			{Name: Name{Local: `two`}, Value: `three`},
+			{Name: Name{Space: `ns`, Local: `two`}, Value: `four`},
+			{Name: Name{Local: `two`}, Value: `five`},
+		},
+	}
+
+	val, ok := AttrList(elem.Attrs).Get(``, `two`)
+	require.True(t, ok)
+	require.Equal(t, `three`, val)
+
+	val, ok = AttrList(elem.Attrs).Get(`ns`, `two`)
+	require.True(t, ok)
+	require.Equal(t, `four`, val)
+
+	_, ok = AttrList(elem.Attrs).Get(``, `six`)
+	require.False(t, ok)
+
+	require.Equal(t, map[Name]string{
+		{Local: `two`}:              `three`,
+		{Space: `ns`, Local: `two`}: `four`,
+	}, AttrList(elem.Attrs).Map())
+	require.Nil(t, AttrList(nil).Map())
+
+	shared := elem.Attrs
+	list := (*AttrList)(&elem.Attrs)
+	list.Set(``, `two`, `six`)
+	list.Set(``, `seven`, `eight`)
+	require.True(t, list.Delete(`ns`, `two`))
+	require.False(t, list.Delete(`ns`, `nine`))
+
+	require.Equal(t, []Attr{
+		{Name: Name{Local: `two`}, Value: `six`},
+		{Name: Name{Local: `two`}, Value: `five`},
+		{Name: Name{Local: `seven`}, Value: `eight`},
+	}, elem.Attrs)
+	require.Equal(t, `four`, shared[1].Value)
+
+	var empty AttrList
+	empty.Set(``, `one`, `two`)
+	require.True(t, empty.Delete(``, `one`))
+	require.Equal(t, AttrList{}, empty)
+}
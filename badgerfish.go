@@ -0,0 +1,294 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+Encodes the nodes as JSON in the "BadgerFish" convention expected by many web
+front-ends, where elements become object keys:
+
+	<a id="1"><b>one</b><b>two</b><c/>three</a>
+	->
+	{"a": {"@id": "1", "b": ["one", "two"], "c": null, "#text": "three"}}
+
+Elements are converted as follows. Elements without attributes and child
+elements become their text, or null when they have no text. Other elements
+become objects with attributes under "@"-prefixed keys, child elements under
+their names, and text under "#text". Keys are in document order: attributes,
+then child elements, then text. Repeated child elements with the same name
+are collected into an array at the position of the first occurrence. All
+values are strings; unlike `Nodes.Unmarshal`, no types are inferred.
+
+This conversion is lossy, and separate from the reversible default JSON
+representation. Namespaces and namespace declarations are dropped, as are
+comments, processing instructions and declarations. Text is trimmed of
+surrounding whitespace, and the relative order of text and child elements,
+and of differently-named child elements, is lost. Non-element top-level nodes
+are ignored. Decode via `FromCompactJSON`.
+*/
+func (self Nodes) ToCompactJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	err := writeCompactElems(&buf, self, nil, "")
+	return buf.Bytes(), err
+}
+
+/*
+Inverse of `Nodes.ToCompactJSON`. The input must be an object whose keys are
+element names. Keys starting with "@" are attributes, "#text" is text, and
+other keys are child elements. Arrays produce repeated elements. Numbers and
+booleans are accepted as text, and null produces an empty element. Key order
+is preserved.
+*/
+func FromCompactJSON(input []byte) (Nodes, error) {
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf(`expected compact JSON object, got %v`, tok)
+	}
+
+	var root Elem
+	err = decodeCompactObject(dec, &root, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if err != io.EOF {
+		return nil, fmt.Errorf(`unexpected data after compact JSON object`)
+	}
+	return root.Nodes, nil
+}
+
+/*
+Writes an object with the attributes, the child elements grouped by name, and
+the text, if not empty.
+*/
+func writeCompactElems(buf *bytes.Buffer, nodes Nodes, attrs []Attr, text string) error {
+	var names []string
+	groups := map[string][]*Elem{}
+	for _, node := range nodes {
+		elem, ok := nodeElem(node)
+		if !ok {
+			continue
+		}
+		local := elem.Name.Local
+		if groups[local] == nil {
+			names = append(names, local)
+		}
+		groups[local] = append(groups[local], elem)
+	}
+
+	buf.WriteByte('{')
+	first := true
+	key := func(val string) error {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		return writeCompactString(buf, val)
+	}
+
+	for _, attr := range attrs {
+		if IsNamespaceDecl(attr) {
+			continue
+		}
+		err := key(`@` + attr.Name.Local)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		err = writeCompactString(buf, attr.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		err := key(name)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+
+		group := groups[name]
+		if len(group) == 1 {
+			err = writeCompactElem(buf, *group[0])
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		buf.WriteByte('[')
+		for ind, elem := range group {
+			if ind > 0 {
+				buf.WriteByte(',')
+			}
+			err = writeCompactElem(buf, *elem)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+
+	if text != "" {
+		err := key(`#text`)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		err = writeCompactString(buf, text)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeCompactElem(buf *bytes.Buffer, elem Elem) error {
+	text := strings.Trim(elem.Nodes.ownText(), whitespace)
+
+	if !hasElems(elem.Nodes) && !hasNonDeclAttrs(elem.Attrs) {
+		if text == "" {
+			buf.WriteString(`null`)
+			return nil
+		}
+		return writeCompactString(buf, text)
+	}
+	return writeCompactElems(buf, elem.Nodes, elem.Attrs, text)
+}
+
+func writeCompactString(buf *bytes.Buffer, val string) error {
+	out, err := json.Marshal(val)
+	buf.Write(out)
+	return err
+}
+
+func hasNonDeclAttrs(attrs []Attr) bool {
+	for _, attr := range attrs {
+		if !IsNamespaceDecl(attr) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Decodes the keys and values of an object whose opening brace has already been
+read, up to and including the closing brace, into the given element.
+*/
+func decodeCompactObject(dec *json.Decoder, out *Elem, root bool) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		switch {
+		case strings.HasPrefix(key, `@`) || key == `#text`:
+			if root {
+				return fmt.Errorf(`unexpected key %q in top-level compact JSON object`, key)
+			}
+
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			val, ok := compactScalar(tok)
+			if !ok {
+				return fmt.Errorf(`expected scalar value for compact JSON key %q, got %v`, key, tok)
+			}
+
+			if key == `#text` {
+				out.Nodes = append(out.Nodes, Text(val))
+			} else {
+				out.Attrs = append(out.Attrs, Attr{Name: Name{Local: key[1:]}, Value: val})
+			}
+
+		default:
+			if !isName(key) {
+				return fmt.Errorf(`invalid element name %q in compact JSON`, key)
+			}
+			err := decodeCompactElems(dec, key, out)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// Decodes a value or an array of values into elements with the given name.
+func decodeCompactElems(dec *json.Decoder, local string, out *Elem) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return decodeCompactElem(dec, local, tok, out)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		err = decodeCompactElem(dec, local, tok, out)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+func decodeCompactElem(dec *json.Decoder, local string, tok json.Token, out *Elem) error {
+	elem := Elem{Name: Name{Local: local}}
+
+	if tok == json.Delim('{') {
+		err := decodeCompactObject(dec, &elem, false)
+		if err != nil {
+			return err
+		}
+	} else if tok != nil {
+		val, ok := compactScalar(tok)
+		if !ok {
+			return fmt.Errorf(`unexpected %v in compact JSON element %q`, tok, local)
+		}
+		if val != "" {
+			elem.Nodes = Nodes{Text(val)}
+		}
+	}
+
+	out.Nodes = append(out.Nodes, elem)
+	return nil
+}
+
+func compactScalar(tok json.Token) (string, bool) {
+	switch tok := tok.(type) {
+	case string:
+		return tok, true
+	case json.Number:
+		return tok.String(), true
+	case bool:
+		return strconv.FormatBool(tok), true
+	}
+	return "", false
+}
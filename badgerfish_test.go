@@ -0,0 +1,63 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToCompactJSON(t *testing.T) {
+	doc, err := Parse([]byte(`<?xml version="1.0"?>
+<a id="1" xmlns:x="two">
+  <b>one</b>
+  <c/>
+  <b x:lang="en">two</b>
+  <!-- comment -->
+  three
+</a>`))
+	require.NoError(t, err)
+
+	out, err := doc.ToCompactJSON()
+	require.NoError(t, err)
+	require.Equal(t, `{"a":{"@id":"1","b":["one",{"@lang":"en","#text":"two"}],"c":null,"#text":"three"}}`, string(out))
+
+	decoded, err := FromCompactJSON(out)
+	require.NoError(t, err)
+	require.Equal(t, Nodes{Elem{
+		Name:  Name{Local: `a`},
+		Attrs: []Attr{{Name{Local: `id`}, `1`}},
+		Nodes: Nodes{
+			Elem{Name: Name{Local: `b`}, Nodes: Nodes{Text(`one`)}},
+			Elem{Name: Name{Local: `b`}, Attrs: []Attr{{Name{Local: `lang`}, `en`}}, Nodes: Nodes{Text(`two`)}},
+			Elem{Name: Name{Local: `c`}},
+			Text(`three`),
+		},
+	}}, decoded)
+
+	again, err := decoded.ToCompactJSON()
+	require.NoError(t, err)
+	require.Equal(t, string(out), string(again))
+
+	out, err = Nodes(nil).ToCompactJSON()
+	require.NoError(t, err)
+	require.Equal(t, `{}`, string(out))
+}
+
+func TestFromCompactJSON(t *testing.T) {
+	decoded, err := FromCompactJSON([]byte(`{"z": {"n": 12, "y": [true, {}], "x": ""}, "a": null}`))
+	require.NoError(t, err)
+	require.Equal(t, Nodes{
+		Elem{Name: Name{Local: `z`}, Nodes: Nodes{
+			Elem{Name: Name{Local: `n`}, Nodes: Nodes{Text(`12`)}},
+			Elem{Name: Name{Local: `y`}, Nodes: Nodes{Text(`true`)}},
+			Elem{Name: Name{Local: `y`}},
+			Elem{Name: Name{Local: `x`}},
+		}},
+		Elem{Name: Name{Local: `a`}},
+	}, decoded)
+
+	for _, input := range []string{`[]`, `{"@a": "b"}`, `{"a": {"@b": {}}}`, `{"a": [[]]}`, `{"1": null}`, `{} {}`, `{"a": `} {
+		_, err := FromCompactJSON([]byte(input))
+		require.Error(t, err, input)
+	}
+}
@@ -0,0 +1,69 @@
+package xt
+
+import "net/url"
+
+/*
+Returns the effective `xml:base` for the last of the given elements, which are
+ordered from the outermost ancestor to the element itself. Each `xml:base`
+attribute is resolved against the base of its ancestors, as specified by
+XML Base. Returns an empty string if no element declares a base.
+*/
+func EffectiveBase(ancestors []*Elem) (string, error) {
+	var base *url.URL
+
+	for _, elem := range ancestors {
+		val, ok := xmlBase(elem)
+		if !ok {
+			continue
+		}
+
+		ref, err := url.Parse(val)
+		if err != nil {
+			return "", err
+		}
+
+		if base == nil {
+			base = ref
+		} else {
+			base = base.ResolveReference(ref)
+		}
+	}
+
+	if base == nil {
+		return "", nil
+	}
+	return base.String(), nil
+}
+
+/*
+Resolves a possibly-relative reference, such as the value of an `href`
+attribute, against the effective `xml:base` of the last of the given elements.
+See `EffectiveBase`. If no base is declared, the reference is returned as-is.
+*/
+func ResolveBase(ancestors []*Elem, ref string) (string, error) {
+	base, err := EffectiveBase(ancestors)
+	if err != nil || base == "" {
+		return ref, err
+	}
+
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	refUrl, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return baseUrl.ResolveReference(refUrl).String(), nil
+}
+
+func xmlBase(elem *Elem) (string, bool) {
+	for _, attr := range elem.Attrs {
+		if attr.Name.Space == NamespaceXML && attr.Name.Local == `base` {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
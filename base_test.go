@@ -0,0 +1,37 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBase(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<feed xml:base="http://example.com/blog/">
+  <entry xml:base="2021/">
+    <link href="post.html" />
+  </entry>
+</feed>`))
+	require.NoError(t, err)
+
+	feed := doc[0].(Elem)
+	entry := feed.Nodes[1].(Elem)
+	link := entry.Nodes[1].(Elem)
+	ancestors := []*Elem{&feed, &entry, &link}
+
+	base, err := EffectiveBase(ancestors)
+	require.NoError(t, err)
+	require.Equal(t, `http://example.com/blog/2021/`, base)
+
+	href, err := ResolveBase(ancestors, link.Attrs[0].Value)
+	require.NoError(t, err)
+	require.Equal(t, `http://example.com/blog/2021/post.html`, href)
+
+	href, err = ResolveBase(ancestors, `/about`)
+	require.NoError(t, err)
+	require.Equal(t, `http://example.com/about`, href)
+
+	href, err = ResolveBase([]*Elem{&link}, `post.html`)
+	require.NoError(t, err)
+	require.Equal(t, `post.html`, href)
+}
@@ -0,0 +1,338 @@
+package xt
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+/*
+Version of the binary format produced by `Nodes.MarshalBinary`. Stored as the
+first byte, so that future versions can detect and reject incompatible data.
+*/
+const binaryVersion = 4
+
+// Node type tags used in the binary format.
+const (
+	binaryPi byte = iota + 1
+	binaryDecl
+	binaryComment
+	binaryText
+	binaryElem
+	binaryNodes
+	binaryCData
+	binaryEntityRef
+	binaryDoctype
+)
+
+var _ = encoding.BinaryMarshaler(Nodes(nil))
+
+/*
+Encodes the nodes in a compact binary format, which is smaller and faster to
+encode and decode than JSON. Useful for caching large trees. Unlike JSON, the
+encoding preserves the distinction between nil and empty slices. The only
+information lost is the distinction between `Elem` and `*Elem`, which is
+decoded as `Elem`.
+
+The format consists of a version byte followed by the nodes. Sequences are
+prefixed with their length plus one, where 0 denotes nil. Strings are prefixed
+with their length. Each node is prefixed with a type tag.
+*/
+func (self Nodes) MarshalBinary() ([]byte, error) {
+	return self.appendBinary([]byte{binaryVersion})
+}
+
+var _ = encoding.BinaryUnmarshaler((*Nodes)(nil))
+
+// Decodes the format produced by `Nodes.MarshalBinary`, replacing the nodes.
+func (self *Nodes) UnmarshalBinary(input []byte) error {
+	if len(input) == 0 {
+		return errBinaryEOF
+	}
+	if input[0] != binaryVersion {
+		return fmt.Errorf(`unsupported binary format version %d`, input[0])
+	}
+
+	dec := binaryDecoder{input: input[1:]}
+	out, err := dec.nodes()
+	if err != nil {
+		return err
+	}
+	if len(dec.input) > 0 {
+		return fmt.Errorf(`unexpected %d trailing bytes in binary input`, len(dec.input))
+	}
+
+	*self = out
+	return nil
+}
+
+func (self Nodes) appendBinary(out []byte) ([]byte, error) {
+	out = appendBinaryLen(out, len(self), self == nil)
+
+	for _, node := range self {
+		var err error
+		out, err = appendBinaryNode(out, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func appendBinaryNode(out []byte, node Node) ([]byte, error) {
+	switch node := node.(type) {
+	case Pi:
+		out = append(out, binaryPi)
+		out = appendBinaryString(out, node.Target)
+		return appendBinaryString(out, node.Content), nil
+
+	case Decl:
+		return appendBinaryString(append(out, binaryDecl), string(node)), nil
+
+	case Comment:
+		return appendBinaryString(append(out, binaryComment), string(node)), nil
+
+	case Text:
+		return appendBinaryString(append(out, binaryText), string(node)), nil
+
+	case CData:
+		return appendBinaryString(append(out, binaryCData), string(node)), nil
+
+	case EntityRef:
+		return appendBinaryString(append(out, binaryEntityRef), string(node)), nil
+
+	case Doctype:
+		out = append(out, binaryDoctype)
+		out = appendBinaryString(out, node.Name)
+		out = appendBinaryString(out, node.PublicID)
+		out = appendBinaryString(out, node.SystemID)
+		return appendBinaryString(out, node.InternalSubset), nil
+
+	case Elem:
+		return node.appendBinary(append(out, binaryElem))
+
+	case *Elem:
+		if node == nil {
+			return nil, fmt.Errorf(`can't binary-encode nil *Elem`)
+		}
+		return node.appendBinary(append(out, binaryElem))
+
+	case Nodes:
+		return node.appendBinary(append(out, binaryNodes))
+	}
+
+	return nil, fmt.Errorf(`can't binary-encode node of unsupported type %T`, node)
+}
+
+func (self Elem) appendBinary(out []byte) ([]byte, error) {
+	out = appendBinaryString(out, self.Name.Space)
+	out = appendBinaryString(out, self.Name.Local)
+	out = appendBinaryString(out, self.Prefix)
+
+	out = appendBinaryLen(out, len(self.Attrs), self.Attrs == nil)
+	for _, attr := range self.Attrs {
+		out = appendBinaryString(out, attr.Name.Space)
+		out = appendBinaryString(out, attr.Name.Local)
+		out = appendBinaryString(out, attr.Value)
+	}
+
+	out = appendBinaryLen(out, len(self.RawAttrs), self.RawAttrs == nil)
+	for _, raw := range self.RawAttrs {
+		out = appendBinaryString(out, raw)
+	}
+
+	return self.Nodes.appendBinary(out)
+}
+
+func appendBinaryLen(out []byte, size int, isNil bool) []byte {
+	if isNil {
+		return appendUvarint(out, 0)
+	}
+	return appendUvarint(out, uint64(size)+1)
+}
+
+func appendBinaryString(out []byte, val string) []byte {
+	out = appendUvarint(out, uint64(len(val)))
+	return append(out, val...)
+}
+
+func appendUvarint(out []byte, val uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(out, buf[:binary.PutUvarint(buf[:], val)]...)
+}
+
+var errBinaryEOF = errors.New(`unexpected end of binary input`)
+
+type binaryDecoder struct {
+	input []byte
+}
+
+func (self *binaryDecoder) nodes() (Nodes, error) {
+	size, isNil, err := self.len()
+	if err != nil || isNil {
+		return nil, err
+	}
+
+	out := make(Nodes, 0, size)
+	for ind := 0; ind < size; ind++ {
+		node, err := self.node()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func (self *binaryDecoder) node() (Node, error) {
+	if len(self.input) == 0 {
+		return nil, errBinaryEOF
+	}
+	tag := self.input[0]
+	self.input = self.input[1:]
+
+	switch tag {
+	case binaryPi:
+		target, err := self.string()
+		if err != nil {
+			return nil, err
+		}
+		content, err := self.string()
+		return Pi{target, content}, err
+
+	case binaryDecl:
+		val, err := self.string()
+		return Decl(val), err
+
+	case binaryComment:
+		val, err := self.string()
+		return Comment(val), err
+
+	case binaryText:
+		val, err := self.string()
+		return Text(val), err
+
+	case binaryCData:
+		val, err := self.string()
+		return CData(val), err
+
+	case binaryEntityRef:
+		val, err := self.string()
+		return EntityRef(val), err
+
+	case binaryDoctype:
+		return self.doctype()
+
+	case binaryElem:
+		return self.elem()
+
+	case binaryNodes:
+		return self.nodes()
+	}
+
+	return nil, fmt.Errorf(`unrecognized binary node tag %d`, tag)
+}
+
+func (self *binaryDecoder) doctype() (out Doctype, err error) {
+	for _, field := range []*string{&out.Name, &out.PublicID, &out.SystemID, &out.InternalSubset} {
+		*field, err = self.string()
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (self *binaryDecoder) elem() (out Elem, err error) {
+	out.Name.Space, err = self.string()
+	if err != nil {
+		return
+	}
+	out.Name.Local, err = self.string()
+	if err != nil {
+		return
+	}
+	out.Prefix, err = self.string()
+	if err != nil {
+		return
+	}
+
+	size, isNil, err := self.len()
+	if err != nil {
+		return
+	}
+	if !isNil {
+		out.Attrs = make([]Attr, size)
+	}
+	for ind := range out.Attrs {
+		attr := &out.Attrs[ind]
+		attr.Name.Space, err = self.string()
+		if err != nil {
+			return
+		}
+		attr.Name.Local, err = self.string()
+		if err != nil {
+			return
+		}
+		attr.Value, err = self.string()
+		if err != nil {
+			return
+		}
+	}
+
+	size, isNil, err = self.len()
+	if err != nil {
+		return
+	}
+	if !isNil {
+		out.RawAttrs = make([]string, size)
+	}
+	for ind := range out.RawAttrs {
+		out.RawAttrs[ind], err = self.string()
+		if err != nil {
+			return
+		}
+	}
+
+	out.Nodes, err = self.nodes()
+	return
+}
+
+func (self *binaryDecoder) len() (int, bool, error) {
+	val, err := self.uvarint()
+	if err != nil {
+		return 0, false, err
+	}
+	if val == 0 {
+		return 0, true, nil
+	}
+	// Each entry takes at least one byte, which prevents huge allocations
+	// on malformed input.
+	if val-1 > uint64(len(self.input)) {
+		return 0, false, errBinaryEOF
+	}
+	return int(val - 1), false, nil
+}
+
+func (self *binaryDecoder) string() (string, error) {
+	size, err := self.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if size > uint64(len(self.input)) {
+		return "", errBinaryEOF
+	}
+	out := string(self.input[:size])
+	self.input = self.input[size:]
+	return out, nil
+}
+
+func (self *binaryDecoder) uvarint() (uint64, error) {
+	val, size := binary.Uvarint(self.input)
+	if size <= 0 {
+		return 0, errBinaryEOF
+	}
+	self.input = self.input[size:]
+	return val, nil
+}
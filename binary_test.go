@@ -0,0 +1,106 @@
+package xt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinary(t *testing.T) {
+	test := func(src Nodes) {
+		t.Helper()
+
+		content, err := src.MarshalBinary()
+		require.NoError(t, err)
+
+		var out Nodes
+		require.NoError(t, out.UnmarshalBinary(content))
+		require.Equal(t, src, out)
+	}
+
+	test(expectedSimple)
+	test(expectedNsAliased)
+	test(expectedNsInlined)
+	test(nil)
+	test(Nodes{})
+	test(Nodes{CData(`one <two>`), Decl(`DOCTYPE html`), Doctype{Name: `html`, SystemID: `about:legacy-compat`}, Elem{Name: Name{Local: `one`}, Attrs: []Attr{}, Nodes: Nodes{}}})
+}
+
+func TestBinaryMalformed(t *testing.T) {
+	content, err := expectedSimple.MarshalBinary()
+	require.NoError(t, err)
+
+	var out Nodes
+	require.Error(t, out.UnmarshalBinary(nil))
+	require.Error(t, out.UnmarshalBinary(content[:len(content)-1]))
+	require.Error(t, out.UnmarshalBinary(append(content, 0)))
+	require.Error(t, out.UnmarshalBinary(append([]byte{binaryVersion + 1}, content[1:]...)))
+	require.Error(t, out.UnmarshalBinary([]byte{1, 2, binaryElem, 1, 'a', 1, 'b', 0, 0}))
+	require.Error(t, out.UnmarshalBinary([]byte{2, 2, binaryElem, 1, 'a', 1, 'b', 1, 'c', 0, 0}))
+}
+
+func TestBinaryNilElem(t *testing.T) {
+	_, err := Nodes{E(`one`).C((*Elem)(nil))}.MarshalBinary()
+	require.EqualError(t, err, `can't binary-encode nil *Elem`)
+}
+
+func TestBinaryRawAttrs(t *testing.T) {
+	doc := Nodes{Elem{
+		Name:     Name{Local: `a`},
+		Attrs:    []Attr{{Name: Name{Local: `b`}, Value: "\t"}},
+		RawAttrs: []string{`&#9;`},
+	}}
+
+	content, err := doc.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, []byte{binaryVersion, 2, binaryElem, 0, 1, 'a', 0, 2, 0, 1, 'b', 1, '\t', 2, 4, '&', '#', '9', ';', 0}, content)
+
+	var out Nodes
+	require.NoError(t, out.UnmarshalBinary(content))
+	require.Equal(t, doc, out)
+}
+
+func BenchmarkMarshalBinary(b *testing.B) {
+	doc := benchDoc(1000)
+	b.ResetTimer()
+
+	var out []byte
+	for ind := 0; ind < b.N; ind++ {
+		out, _ = doc.MarshalBinary()
+	}
+	b.ReportMetric(float64(len(out)), `bytes`)
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	doc := benchDoc(1000)
+	b.ResetTimer()
+
+	var out []byte
+	for ind := 0; ind < b.N; ind++ {
+		out, _ = json.Marshal(doc)
+	}
+	b.ReportMetric(float64(len(out)), `bytes`)
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	content, err := benchDoc(1000).MarshalBinary()
+	require.NoError(b, err)
+	b.ResetTimer()
+
+	for ind := 0; ind < b.N; ind++ {
+		var out Nodes
+		_ = out.UnmarshalBinary(content)
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	content, err := json.Marshal(benchDoc(1000))
+	require.NoError(b, err)
+	b.ResetTimer()
+
+	for ind := 0; ind < b.N; ind++ {
+		var out Nodes
+		_ = json.Unmarshal(content, &out)
+	}
+}
@@ -0,0 +1,121 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+/*
+Decodes the element into `v`, like `xml.Unmarshal`, which allows to bind a
+subtree of a generically-decoded document to a struct with the usual
+`encoding/xml` tags. Instead of encoding the element to XML and decoding it
+again, this replays its tokens to an `xml.Decoder` via `xml.NewTokenDecoder`.
+
+Names are replayed as-is: `Name.Space` must be the namespace URI, as produced
+by decoding, and `Prefix` is ignored. `CData` is replayed as regular text.
+`EntityRef` is an error, because `encoding/xml` can't represent it.
+*/
+func (self Elem) Unmarshal(v interface{}) error {
+	if self.Name.Local == "" {
+		return fmt.Errorf(`can't XML-decode %T with empty name`, self)
+	}
+	reader := &tokenReader{stack: []tokenFrame{{nodes: Nodes{self}}}}
+	return xml.NewTokenDecoder(reader).Decode(v)
+}
+
+/*
+Inverse of `Elem.Unmarshal`: encodes `v` via `xml.Marshal`, which respects the
+usual `encoding/xml` tags and `xml.Marshaler`, and decodes the result into
+nodes, which can be spliced into another tree. When the output consists of a
+single node, such as an element, char data, or a processing instruction, the
+node is returned as-is. Otherwise the result is `Nodes`, which is empty when
+`v` encodes to nothing, such as a nil pointer.
+*/
+func FromValue(v interface{}) (Node, error) {
+	src, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out Nodes
+	err = out.Decode(xml.NewDecoder(bytes.NewReader(src)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) == 1 {
+		return out[0], nil
+	}
+	return out, nil
+}
+
+/*
+Implements `xml.TokenReader` by walking the nodes, without serializing them.
+Each frame holds the remaining nodes of an element or `Nodes`, and the end
+tag to emit once they're exhausted.
+*/
+type tokenReader struct{ stack []tokenFrame }
+
+type tokenFrame struct {
+	nodes  Nodes
+	end    xml.EndElement
+	hasEnd bool
+}
+
+func (self *tokenReader) Token() (xml.Token, error) {
+	for len(self.stack) > 0 {
+		top := &self.stack[len(self.stack)-1]
+
+		if len(top.nodes) == 0 {
+			self.stack = self.stack[:len(self.stack)-1]
+			if top.hasEnd {
+				return top.end, nil
+			}
+			continue
+		}
+
+		node := top.nodes[0]
+		top.nodes = top.nodes[1:]
+
+		switch node := node.(type) {
+		case Pi:
+			return xml.ProcInst{Target: node.Target, Inst: []byte(node.Content)}, nil
+		case Decl:
+			return xml.Directive(node), nil
+		case Doctype:
+			return xml.Directive(node.Decl()), nil
+		case Comment:
+			return xml.Comment(node), nil
+		case Text:
+			return xml.CharData(node), nil
+		case CData:
+			return xml.CharData(node), nil
+		case EntityRef:
+			return nil, fmt.Errorf(`can't decode XML entity reference &%s; via encoding/xml`, string(node))
+		case Elem:
+			return self.start(node), nil
+		case *Elem:
+			if node != nil {
+				return self.start(*node), nil
+			}
+		case Nodes:
+			self.stack = append(self.stack, tokenFrame{nodes: node})
+		}
+	}
+	return nil, io.EOF
+}
+
+func (self *tokenReader) start(elem Elem) xml.Token {
+	name := xml.Name(elem.Name)
+	self.stack = append(self.stack, tokenFrame{
+		nodes:  elem.Nodes,
+		end:    xml.EndElement{Name: name},
+		hasEnd: true,
+	})
+
+	// The decoder translates attribute names in-place, so this must not share
+	// memory with the element.
+	return xml.CopyToken(xml.StartElement{Name: name, Attr: attrsTo(elem.Attrs)})
+}
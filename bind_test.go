@@ -0,0 +1,93 @@
+package xt
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElemUnmarshal(t *testing.T) {
+	src := []byte(`<root><items xmlns:n="five"><item id="1" n:two="three">one<![CDATA[ & two]]></item><!-- skip --><item id="2"><sub>four</sub></item></items></root>`)
+
+	type Item struct {
+		Id   string `xml:"id,attr"`
+		Two  string `xml:"five two,attr"`
+		Text string `xml:",chardata"`
+		Sub  string `xml:"sub"`
+	}
+	type Items struct {
+		XMLName xml.Name `xml:"items"`
+		Items   []Item   `xml:"item"`
+	}
+
+	doc, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+
+	root := doc[0].(Elem)
+	elem := root.Find(``, `items`)
+	require.NotNil(t, elem)
+
+	var out Items
+	require.NoError(t, elem.Unmarshal(&out))
+	require.Equal(t, Items{
+		XMLName: xml.Name{Local: `items`},
+		Items: []Item{
+			{Id: `1`, Two: `three`, Text: `one & two`},
+			{Id: `2`, Sub: `four`},
+		},
+	}, out)
+
+	var other Items
+	require.NoError(t, xml.Unmarshal(src[len(`<root>`):len(src)-len(`</root>`)], &other))
+	require.Equal(t, other, out)
+
+	require.EqualError(t, Elem{}.Unmarshal(&out), `can't XML-decode xt.Elem with empty name`)
+
+	err = Elem{Name: Name{Local: `items`}, Nodes: Nodes{EntityRef(`copy`)}}.Unmarshal(&out)
+	require.EqualError(t, err, `can't decode XML entity reference &copy; via encoding/xml`)
+}
+
+func TestFromValue(t *testing.T) {
+	type Item struct {
+		XMLName xml.Name `xml:"five item"`
+		Id      string   `xml:"id,attr"`
+		Text    string   `xml:",chardata"`
+		Sub     string   `xml:"sub,omitempty"`
+	}
+
+	node, err := FromValue(Item{Id: `1`, Text: `one & two`, Sub: `three`})
+	require.NoError(t, err)
+	require.Equal(t, Elem{
+		Name:  Name{Space: `five`, Local: `item`},
+		Attrs: []Attr{{Name{Local: `xmlns`}, `five`}, {Name{Local: `id`}, `1`}},
+		Nodes: Nodes{
+			Text(`one & two`),
+			Elem{Name: Name{Space: `five`, Local: `sub`}, Attrs: []Attr{}, Nodes: Nodes{Text(`three`)}},
+		},
+	}, node)
+
+	var item Item
+	require.NoError(t, node.(Elem).Unmarshal(&item))
+	require.Equal(t, Item{XMLName: xml.Name{Space: `five`, Local: `item`}, Id: `1`, Text: `one & two`, Sub: `three`}, item)
+
+	node, err = FromValue([]Item{{Id: `1`}, {Id: `2`}})
+	require.NoError(t, err)
+	require.Len(t, node, 2)
+	require.Equal(t, `2`, node.(Nodes)[1].(Elem).Attrs[1].Value)
+
+	node, err = FromValue(Text(`one`))
+	require.NoError(t, err)
+	require.Equal(t, Text(`one`), node)
+
+	node, err = FromValue(Pi{Target: `one`, Content: `two`})
+	require.NoError(t, err)
+	require.Equal(t, Pi{Target: `one`, Content: `two`}, node)
+
+	node, err = FromValue((*Item)(nil))
+	require.NoError(t, err)
+	require.Equal(t, Nodes(nil), node)
+
+	_, err = FromValue(func() {})
+	require.Error(t, err)
+}
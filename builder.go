@@ -0,0 +1,65 @@
+package xt
+
+/*
+Creates an element with the given local name, for building trees fluently
+together with `(*Elem).A`, `(*Elem).C` and `(*Elem).NS`:
+
+	E("div").A("class", "x").C(E("span").C(Text("hi")))
+
+The result is a regular `*Elem`, which is a valid `Node`.
+*/
+func E(local string) *Elem {
+	return &Elem{Name: Name{Local: local}}
+}
+
+/*
+Sets an attribute without a namespace, like `(*Elem).SetAttr`, and returns the
+same element for chaining.
+*/
+func (self *Elem) A(local, value string) *Elem {
+	self.SetAttr("", local, value)
+	return self
+}
+
+/*
+Appends child nodes and returns the same element for chaining. The children
+may include other builders, since `*Elem` is a valid `Node`.
+*/
+func (self *Elem) C(children ...Node) *Elem {
+	self.Nodes = append(self.Nodes, children...)
+	return self
+}
+
+/*
+Sets the element's namespace and returns the same element for chaining. This
+doesn't add an `xmlns` attribute; when encoding, the namespace is declared as
+needed.
+*/
+func (self *Elem) NS(space string) *Elem {
+	self.Name.Space = space
+	return self
+}
+
+/*
+Creates an element with the given namespace URI and local name. Unlike
+`encoding/xml`, `MarshalOptions` declares the namespaces used by elements and
+attributes automatically, with deterministic output and without requiring
+`xmlns` attributes:
+
+	MarshalOptions{SelfClose: true}.Marshal(NewElem("urn:foo", "bar"))
+	// <bar xmlns="urn:foo"/>
+
+For chaining, see `E` and `(*Elem).NS`.
+*/
+func NewElem(space, local string) Elem {
+	return Elem{Name: Name{Space: space, Local: local}}
+}
+
+/*
+Creates an attribute with the given namespace URI, local name and value. When
+encoding via `MarshalOptions`, the attribute reuses a prefix already declared
+for the namespace in scope, or declares a new one derived from the URI.
+*/
+func NewAttr(space, local, value string) Attr {
+	return Attr{Name: Name{Space: space, Local: local}, Value: value}
+}
@@ -0,0 +1,54 @@
+package xt
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	elem := E(`div`).A(`class`, `x`).A(`id`, `one`).A(`class`, `y`).C(
+		E(`span`).C(Text(`hi`)),
+		Comment(`two`),
+		E(`svg`).NS(`http://www.w3.org/2000/svg`),
+	)
+
+	require.Equal(t, &Elem{
+		Name:  Name{Local: `div`},
+		Attrs: []Attr{{Name{Local: `class`}, `y`}, {Name{Local: `id`}, `one`}},
+		Nodes: Nodes{
+			&Elem{Name: Name{Local: `span`}, Nodes: Nodes{Text(`hi`)}},
+			Comment(`two`),
+			&Elem{Name: Name{Space: `http://www.w3.org/2000/svg`, Local: `svg`}},
+		},
+	}, elem)
+
+	out, err := xml.Marshal(Nodes{elem})
+	require.NoError(t, err)
+	require.Equal(t, `<div class="y" id="one"><span>hi</span><!--two--><svg xmlns="http://www.w3.org/2000/svg"></svg></div>`, string(out))
+
+	out, err = json.Marshal(Nodes{elem})
+	require.NoError(t, err)
+
+	var decoded Nodes
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.True(t, Equal(Nodes{elem}, decoded, EqualOptions{}))
+}
+
+func TestNewElem(t *testing.T) {
+	elem := NewElem(`urn:foo`, `bar`)
+	require.Equal(t, Elem{Name: Name{Space: `urn:foo`, Local: `bar`}}, elem)
+
+	out, err := MarshalOptions{SelfClose: true}.Marshal(elem)
+	require.NoError(t, err)
+	require.Equal(t, `<bar xmlns="urn:foo"/>`, string(out))
+
+	elem.Attrs = append(elem.Attrs, NewAttr(`http://example.com/qux`, `one`, `two`))
+	elem.Nodes = Nodes{NewElem(`urn:foo`, `three`), NewElem(`urn:other`, `four`)}
+
+	out, err = MarshalOptions{SelfClose: true, DedupeNamespaces: true}.Marshal(elem)
+	require.NoError(t, err)
+	require.Equal(t, `<bar xmlns="urn:foo" xmlns:qux="http://example.com/qux" qux:one="two"><three/><four xmlns="urn:other"/></bar>`, string(out))
+}
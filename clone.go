@@ -0,0 +1,52 @@
+package xt
+
+/*
+Returns a deep copy of the nodes, which can be modified without affecting the
+original. Elements are copied along with their attributes and descendants into
+new backing arrays; elements stored as `*Elem` are copied into new pointers.
+Other node types, such as `Text`, are immutable values and are copied as-is.
+
+The distinction between nil and empty slices is preserved: nil `Nodes` and
+`Attrs` remain nil, and empty ones remain empty, so the copy is equal to the
+original via `reflect.DeepEqual` and `require.Equal`.
+*/
+func (self Nodes) Clone() Nodes {
+	if self == nil {
+		return nil
+	}
+
+	out := make(Nodes, len(self))
+	for ind, node := range self {
+		out[ind] = cloneNode(node)
+	}
+	return out
+}
+
+// Returns a deep copy of the element. See `Nodes.Clone`.
+func (self Elem) Clone() Elem {
+	if self.Attrs != nil {
+		self.Attrs = append(make([]Attr, 0, len(self.Attrs)), self.Attrs...)
+	}
+	if self.RawAttrs != nil {
+		self.RawAttrs = append(make([]string, 0, len(self.RawAttrs)), self.RawAttrs...)
+	}
+	self.Nodes = self.Nodes.Clone()
+	return self
+}
+
+func cloneNode(node Node) Node {
+	switch node := node.(type) {
+	case Elem:
+		return node.Clone()
+	case *Elem:
+		if node == nil {
+			return node
+		}
+		elem := node.Clone()
+		return &elem
+	case Nodes:
+		return node.Clone()
+	default:
+		return node
+	}
+}
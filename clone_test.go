@@ -0,0 +1,55 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `simple.xml`))
+	require.NoError(t, err)
+
+	ptr := &Elem{Name: Name{Local: `one`}, Attrs: []Attr{{Name: Name{Local: `two`}, Value: `three`}}}
+	doc = append(doc, ptr, Nodes{Elem{Name: Name{Local: `four`}}}, Nodes{})
+
+	clone := doc.Clone()
+	require.Equal(t, doc, clone)
+	require.Nil(t, Nodes(nil).Clone())
+
+	for ind, node := range clone {
+		elem, ok := node.(Elem)
+		if !ok {
+			continue
+		}
+		elem.Attrs[0].Value = `changed`
+		elem.Nodes[0] = Text(`changed`)
+		require.NotEqual(t, doc[ind], clone[ind])
+	}
+
+	clonedPtr := clone[len(clone)-3].(*Elem)
+	require.False(t, clonedPtr == ptr)
+	clonedPtr.Attrs[0].Value = `changed`
+	require.Equal(t, `three`, ptr.Attrs[0].Value)
+
+	clone[len(clone)-2].(Nodes)[0] = Text(`changed`)
+	require.Equal(t, Elem{Name: Name{Local: `four`}}, doc[len(doc)-2].(Nodes)[0])
+
+	require.Equal(t, expectedSimple, doc[:len(expectedSimple)])
+}
+
+func TestElemClone(t *testing.T) {
+	elem := Elem{
+		Name:  Name{Local: `one`},
+		Attrs: []Attr{},
+		Nodes: Nodes{Elem{Name: Name{Local: `two`}, Attrs: []Attr{{Name: Name{Local: `three`}}}}},
+	}
+
+	clone := elem.Clone()
+	require.Equal(t, elem, clone)
+	require.NotNil(t, clone.Attrs)
+	require.Nil(t, clone.Nodes[0].(Elem).Nodes)
+
+	clone.Nodes[0].(Elem).Attrs[0].Value = `four`
+	require.Equal(t, ``, elem.Nodes[0].(Elem).Attrs[0].Value)
+}
@@ -0,0 +1,159 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+Alternative, more compact JSON representation of `Nodes`. Use it by converting
+`Nodes` to `CompactJSON` before encoding or decoding. The default
+representation is unaffected.
+
+Elements whose only child is a single text node are collapsed, storing the
+text in the "text" field instead of "nodes":
+
+	<one two="three">four</one>
+	<->
+	{"type": "elem", "name": {"local": "one"}, "attrs": [...], "text": "four"}
+
+Only such text-only elements are collapsed. Any other content, including
+comments, processing instructions or multiple text nodes, forces the regular
+"nodes" form, so that no information is lost. For example, `<one>two<!---->
+</one>` is not collapsed.
+
+Other text nodes are encoded as bare JSON strings, and strings in arrays are
+decoded as text nodes:
+
+	<one>two<!--three--></one>
+	<->
+	{"type": "elem", "name": {"local": "one"}, "nodes": ["two", {"type": "comment", "content": "three"}]}
+
+When decoding, the regular `{"type": "text"}` form is also accepted. Other
+node types are encoded as usual.
+*/
+type CompactJSON Nodes
+
+var _ = json.Marshaler(CompactJSON(nil))
+
+func (self CompactJSON) MarshalJSON() ([]byte, error) {
+	if self == nil {
+		return []byte(`null`), nil
+	}
+
+	out := make([]interface{}, 0, len(self))
+	for _, node := range self {
+		if text, ok := node.(Text); ok {
+			out = append(out, string(text))
+			continue
+		}
+
+		elem, ok := nodeElem(node)
+		if ok {
+			out = append(out, compactElemFrom(*elem))
+		} else {
+			out = append(out, node)
+		}
+	}
+	return json.Marshal(out)
+}
+
+var _ = json.Unmarshaler((*CompactJSON)(nil))
+
+func (self *CompactJSON) UnmarshalJSON(input []byte) error {
+	var items []json.RawMessage
+	err := json.Unmarshal(input, &items)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		*self = nil
+		return nil
+	}
+
+	out := make(CompactJSON, 0, len(items))
+	for _, item := range items {
+		node, err := unmarshalCompactNode(item)
+		if err != nil {
+			return err
+		}
+		out = append(out, node)
+	}
+
+	*self = out
+	return nil
+}
+
+type compactElem struct {
+	typeHead
+	Name     Name        `json:"name,omitempty"`
+	Prefix   string      `json:"prefix,omitempty"`
+	Attrs    []Attr      `json:"attrs,omitempty"`
+	RawAttrs []string    `json:"rawAttrs,omitempty"`
+	Text     *string     `json:"text,omitempty"`
+	Nodes    CompactJSON `json:"nodes,omitempty"`
+}
+
+func compactElemFrom(elem Elem) compactElem {
+	out := compactElem{
+		typeHead: typeHead{TypeElem},
+		Name:     elem.Name,
+		Prefix:   elem.Prefix,
+		Attrs:    elem.Attrs,
+		RawAttrs: elem.RawAttrs,
+	}
+
+	text, ok := soleText(elem.Nodes)
+	if ok {
+		out.Text = &text
+	} else {
+		out.Nodes = CompactJSON(elem.Nodes)
+	}
+	return out
+}
+
+func unmarshalCompactNode(input []byte) (Node, error) {
+	input = bytes.TrimLeft(input, whitespace)
+	if len(input) > 0 && input[0] == '"' {
+		var val string
+		err := json.Unmarshal(input, &val)
+		return Text(val), err
+	}
+
+	var head typeHead
+	err := json.Unmarshal(input, &head)
+	if err != nil {
+		return nil, err
+	}
+
+	if head.Type != TypeElem {
+		var node nodeDecoder
+		err := json.Unmarshal(input, &node)
+		return node.Node, err
+	}
+
+	var val compactElem
+	err = json.Unmarshal(input, &val)
+	if err != nil {
+		return nil, err
+	}
+
+	out := Elem{Name: val.Name, Prefix: val.Prefix, Attrs: val.Attrs, RawAttrs: val.RawAttrs, Nodes: Nodes(val.Nodes)}
+	if val.Text != nil {
+		if val.Nodes != nil {
+			return nil, fmt.Errorf(`compact JSON element can't have both "text" and "nodes" in %q`, input)
+		}
+		out.Nodes = Nodes{Text(*val.Text)}
+	}
+	return out, nil
+}
+
+// If the nodes consist of exactly one text node, returns its content.
+func soleText(nodes Nodes) (string, bool) {
+	if len(nodes) != 1 {
+		return "", false
+	}
+	val, ok := nodes[0].(Text)
+	return string(val), ok
+}
@@ -0,0 +1,112 @@
+package xt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactJSON(t *testing.T) {
+	doc := Nodes{
+		Elem{
+			Name: Name{Local: `one`},
+			Nodes: Nodes{
+				Elem{
+					Name:  Name{Local: `two`},
+					Attrs: []Attr{{Name: Name{Local: `three`}, Value: `four`}},
+					Nodes: Nodes{Text(`five`)},
+				},
+				Elem{Name: Name{Local: `six`}},
+			},
+		},
+	}
+
+	out, err := json.Marshal(CompactJSON(doc))
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"local":"one"},"nodes":[{"type":"elem","name":{"local":"two"},"attrs":[{"name":{"local":"three"},"value":"four"}],"text":"five"},{"type":"elem","name":{"local":"six"}}]}]`, string(out))
+
+	var decoded CompactJSON
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, doc, Nodes(decoded))
+}
+
+func TestCompactJSONPreservesComments(t *testing.T) {
+	doc := Nodes{
+		Elem{
+			Name:  Name{Local: `one`},
+			Nodes: Nodes{Text(`two`), Comment(`three`)},
+		},
+	}
+
+	out, err := json.Marshal(CompactJSON(doc))
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"local":"one"},"nodes":["two",{"type":"comment","content":"three"}]}]`, string(out))
+
+	var decoded CompactJSON
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, doc, Nodes(decoded))
+}
+
+func TestCompactJSONBareText(t *testing.T) {
+	doc := Nodes{
+		Text("\n"),
+		Elem{Name: Name{Local: `one`}, Nodes: Nodes{Text(`two`), Elem{Name: Name{Local: `three`}}, Text(``)}},
+	}
+
+	out, err := json.Marshal(CompactJSON(doc))
+	require.NoError(t, err)
+	require.Equal(t, `["\n",{"type":"elem","name":{"local":"one"},"nodes":["two",{"type":"elem","name":{"local":"three"}},""]}]`, string(out))
+
+	var decoded CompactJSON
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, doc, Nodes(decoded))
+
+	require.NoError(t, json.Unmarshal([]byte(`[ "one", {"type": "text", "content": "two"}]`), &decoded))
+	require.Equal(t, CompactJSON{Text(`one`), Text(`two`)}, decoded)
+
+	require.Error(t, json.Unmarshal([]byte(`["one", 2]`), &decoded))
+}
+
+func TestCompactJSONRoundTrip(t *testing.T) {
+	out, err := json.Marshal(CompactJSON(expectedSimple))
+	require.NoError(t, err)
+
+	var decoded CompactJSON
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, expectedSimple, Nodes(decoded))
+}
+
+func TestCompactJSONPrefix(t *testing.T) {
+	doc := Nodes{Elem{
+		Name:   Name{Space: `ns_p`, Local: `one`},
+		Prefix: `p`,
+		Nodes:  Nodes{Elem{Name: Name{Space: `ns_p`, Local: `two`}, Prefix: `p`, Nodes: Nodes{Text(`three`)}}},
+	}}
+
+	out, err := json.Marshal(CompactJSON(doc))
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"space":"ns_p","local":"one"},"prefix":"p","nodes":[{"type":"elem","name":{"space":"ns_p","local":"two"},"prefix":"p","text":"three"}]}]`, string(out))
+
+	var decoded CompactJSON
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, doc, Nodes(decoded))
+}
+
+func TestCompactJSONRawAttrs(t *testing.T) {
+	src := []byte(`<a b="one&#9;two"/>`)
+	doc, err := Parser{RawAttrs: true}.Parse(src)
+	require.NoError(t, err)
+
+	out, err := json.Marshal(CompactJSON(doc))
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"local":"a"},"attrs":[{"name":{"local":"b"},"value":"one\ttwo"}],"rawAttrs":["one\u0026#9;two"]}]`, string(out))
+
+	var decoded CompactJSON
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, doc, Nodes(decoded))
+
+	content, err := MarshalOptions{SelfClose: true}.Marshal(Nodes(decoded))
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(content))
+}
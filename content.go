@@ -0,0 +1,212 @@
+package xt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind of a `ContentModel` particle.
+type ContentKind byte
+
+const (
+	// Matches one child element with the given name.
+	ContentElem ContentKind = iota
+
+	// Matches all items, in order.
+	ContentSeq
+
+	// Matches exactly one of the items.
+	ContentChoice
+)
+
+// Number of times a `ContentModel` particle may occur, as in DTDs.
+type Occurrence byte
+
+const (
+	// Exactly once. No suffix in DTDs.
+	Once Occurrence = iota
+
+	// Zero or one times. "?" in DTDs.
+	Optional
+
+	// Zero or more times. "*" in DTDs.
+	ZeroOrMore
+
+	// One or more times. "+" in DTDs.
+	OneOrMore
+)
+
+/*
+Describes the allowed child elements of an element, similar to the content
+models of DTD element declarations. For example, the DTD model
+`(title, (para | list)*, note?)` is equivalent to:
+
+	ContentModel{Kind: ContentSeq, Items: []ContentModel{
+		{Name: Name{Local: "title"}},
+		{Kind: ContentChoice, Occurs: ZeroOrMore, Items: []ContentModel{
+			{Name: Name{Local: "para"}},
+			{Name: Name{Local: "list"}},
+		}},
+		{Name: Name{Local: "note"}, Occurs: Optional},
+	}}
+
+Names are matched exactly, including namespace.
+*/
+type ContentModel struct {
+	Kind   ContentKind
+	Name   Name
+	Items  []ContentModel
+	Occurs Occurrence
+}
+
+/*
+Checks the element's child elements against the content model, returning an
+error describing the first violation, or nil if the content is valid. Only
+child elements are validated; text and other nodes are ignored. Descendants
+are not validated.
+*/
+func (self Elem) ValidateContent(model ContentModel) error {
+	var children []Name
+	var indexes []int
+	for ind, node := range self.Nodes {
+		elem, ok := nodeElem(node)
+		if ok {
+			children = append(children, elem.Name)
+			indexes = append(indexes, ind)
+		}
+	}
+
+	match := contentMatcher{names: children, expected: map[Name]bool{}}
+	ends := match.ends(model, 0)
+	if hasInt(ends, len(children)) {
+		return nil
+	}
+
+	expected := match.expectedNames(hasInt(ends, match.furthest))
+	if match.furthest < len(children) {
+		return fmt.Errorf(
+			`invalid content of <%s> at %v: unexpected element <%s>, expected %v`,
+			self.Name.clark(), Path{indexes[match.furthest]}, children[match.furthest].clark(), expected,
+		)
+	}
+	return fmt.Errorf(`invalid content of <%s>: unexpected end of content, expected %v`, self.Name.clark(), expected)
+}
+
+/*
+Matches a sequence of names against a content model by computing all possible
+end positions of each particle, which handles ambiguous models without
+backtracking. Also tracks the furthest position reached, and the names
+expected there, for error reporting.
+*/
+type contentMatcher struct {
+	names    []Name
+	furthest int
+	expected map[Name]bool
+}
+
+func (self *contentMatcher) ends(model ContentModel, start int) []int {
+	switch model.Occurs {
+	case Optional:
+		return union([]int{start}, self.once(model, start))
+	case ZeroOrMore:
+		return self.repeat(model, []int{start})
+	case OneOrMore:
+		return self.repeat(model, self.once(model, start))
+	default:
+		return self.once(model, start)
+	}
+}
+
+func (self *contentMatcher) repeat(model ContentModel, starts []int) []int {
+	out := starts
+	next := starts
+	for len(next) > 0 {
+		var found []int
+		for _, start := range next {
+			for _, end := range self.once(model, start) {
+				if !hasInt(out, end) && !hasInt(found, end) {
+					found = append(found, end)
+				}
+			}
+		}
+		out = union(out, found)
+		next = found
+	}
+	return out
+}
+
+func (self *contentMatcher) once(model ContentModel, start int) []int {
+	switch model.Kind {
+	case ContentSeq:
+		pos := []int{start}
+		for _, item := range model.Items {
+			var next []int
+			for _, val := range pos {
+				next = union(next, self.ends(item, val))
+			}
+			pos = next
+		}
+		return pos
+
+	case ContentChoice:
+		var out []int
+		for _, item := range model.Items {
+			out = union(out, self.ends(item, start))
+		}
+		return out
+
+	default:
+		self.expect(model.Name, start)
+		if start < len(self.names) && self.names[start] == model.Name {
+			self.reach(start + 1)
+			return []int{start + 1}
+		}
+		return nil
+	}
+}
+
+func (self *contentMatcher) expect(name Name, pos int) {
+	self.reach(pos)
+	if pos == self.furthest {
+		self.expected[name] = true
+	}
+}
+
+func (self *contentMatcher) reach(pos int) {
+	if pos > self.furthest {
+		self.furthest = pos
+		self.expected = map[Name]bool{}
+	}
+}
+
+func (self *contentMatcher) expectedNames(canEnd bool) string {
+	var names []string
+	for name := range self.expected {
+		names = append(names, `<`+name.clark()+`>`)
+	}
+	sort.Strings(names)
+
+	if canEnd {
+		names = append(names, `end of content`)
+	}
+	return strings.Join(names, ` or `)
+}
+
+func union(a, b []int) []int {
+	for _, val := range b {
+		if !hasInt(a, val) {
+			a = append(a, val)
+		}
+	}
+	return a
+}
+
+func hasInt(list []int, val int) bool {
+	for _, elem := range list {
+		if elem == val {
+			return true
+		}
+	}
+	return false
+}
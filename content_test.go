@@ -0,0 +1,69 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateContent(t *testing.T) {
+	model := ContentModel{Kind: ContentSeq, Items: []ContentModel{
+		{Name: Name{Local: `title`}},
+		{Kind: ContentChoice, Occurs: ZeroOrMore, Items: []ContentModel{
+			{Name: Name{Local: `para`}},
+			{Name: Name{Local: `list`}},
+		}},
+		{Name: Name{Local: `note`}, Occurs: Optional},
+	}}
+
+	test := func(src string, expected string) {
+		t.Helper()
+
+		doc, err := Parser{}.Parse([]byte(src))
+		require.NoError(t, err)
+
+		err = doc[0].(Elem).ValidateContent(model)
+		if expected == `` {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, expected)
+		}
+	}
+
+	test(`<doc><title/></doc>`, ``)
+	test(`<doc> <title/> text <para/><list/><para/> <note/> </doc>`, ``)
+	test(`<doc><title/><note/></doc>`, ``)
+
+	test(
+		`<doc><para/><title/></doc>`,
+		`invalid content of <doc> at nodes[0]: unexpected element <para>, expected <title>`,
+	)
+	test(
+		`<doc><title/><para/><note/><para/></doc>`,
+		`invalid content of <doc> at nodes[3]: unexpected element <para>, expected end of content`,
+	)
+	test(
+		`<doc><title/> <list/> <other/></doc>`,
+		`invalid content of <doc> at nodes[4]: unexpected element <other>, expected <list> or <note> or <para> or end of content`,
+	)
+	test(
+		`<doc></doc>`,
+		`invalid content of <doc>: unexpected end of content, expected <title>`,
+	)
+}
+
+func TestValidateContentOneOrMore(t *testing.T) {
+	model := ContentModel{Name: Name{Space: `ns`, Local: `item`}, Occurs: OneOrMore}
+
+	doc, err := Parser{}.Parse([]byte(`<list xmlns="ns"><item/><item/></list>`))
+	require.NoError(t, err)
+	require.NoError(t, doc[0].(Elem).ValidateContent(model))
+
+	doc, err = Parser{}.Parse([]byte(`<list xmlns="ns"></list>`))
+	require.NoError(t, err)
+	require.EqualError(
+		t,
+		doc[0].(Elem).ValidateContent(model),
+		`invalid content of <{ns}list>: unexpected end of content, expected <{ns}item>`,
+	)
+}
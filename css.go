@@ -0,0 +1,273 @@
+package xt
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Finds the first descendant element matching a minimal subset of CSS selectors,
+depth-first in document order, or nil if none is found. Supported syntax:
+
+	span                tag name, matching the local name in any namespace
+	*                   any element
+	[class]             presence of an attribute
+	[class="foo"]       attribute value; quotes are optional for simple values
+	.foo                same as [class~="foo"]: one of whitespace-separated words
+	#foo                same as [id="foo"]
+	div span            descendant combinator
+	div > span          child combinator
+
+Like in the DOM, the element itself is not a candidate, but participates in
+matching combinators: `div > span` finds spans which are children of the
+element if it's a div. Names containing "." or ":" are not supported. Invalid
+selectors are an error.
+
+For descendants stored as `*Elem`, returns the same pointer. For descendants
+stored as `Elem`, returns a pointer to a copy, like `Elem.Find`.
+*/
+func (self Elem) QuerySelector(sel string) (*Elem, error) {
+	var out *Elem
+	err := self.query(sel, func(elem *Elem) bool {
+		out = elem
+		return false
+	})
+	return out, err
+}
+
+/*
+Same as `Elem.QuerySelector`, but returns all matching descendants in document
+order. Returns nil if none are found.
+*/
+func (self Elem) QuerySelectorAll(sel string) ([]*Elem, error) {
+	var out []*Elem
+	err := self.query(sel, func(elem *Elem) bool {
+		out = append(out, elem)
+		return true
+	})
+	return out, err
+}
+
+func (self Elem) query(sel string, fn func(*Elem) bool) error {
+	parsed, err := parseSelector(sel)
+	if err != nil {
+		return err
+	}
+	parsed.each(self.Nodes, []*Elem{&self}, fn)
+	return nil
+}
+
+/*
+Sequence of compound selectors such as `div.foo`, separated by combinators.
+`combinators[ind]` is the combinator between `compounds[ind]` and
+`compounds[ind+1]`: either ' ' or '>'.
+*/
+type cssSelector struct {
+	compounds   []cssCompound
+	combinators []byte
+}
+
+type cssCompound struct {
+	local string
+	attrs []cssAttr
+}
+
+type cssAttr struct {
+	local string
+	value string
+	op    byte
+}
+
+// Calls `fn` for each matching descendant until it returns false.
+func (self cssSelector) each(nodes Nodes, ancestors []*Elem, fn func(*Elem) bool) bool {
+	for _, node := range nodes {
+		elem, ok := nodeElem(node)
+		if !ok {
+			continue
+		}
+		if self.match(len(self.compounds)-1, elem, ancestors) && !fn(elem) {
+			return false
+		}
+		if !self.each(elem.Nodes, append(ancestors, elem), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches the compounds up to `ind` from right to left.
+func (self cssSelector) match(ind int, elem *Elem, ancestors []*Elem) bool {
+	if !self.compounds[ind].match(elem) {
+		return false
+	}
+	if ind == 0 {
+		return true
+	}
+
+	last := len(ancestors) - 1
+	if self.combinators[ind-1] == '>' {
+		return last >= 0 && self.match(ind-1, ancestors[last], ancestors[:last])
+	}
+
+	for pos := last; pos >= 0; pos-- {
+		if self.match(ind-1, ancestors[pos], ancestors[:pos]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self cssCompound) match(elem *Elem) bool {
+	if self.local != "" && self.local != elem.Name.Local {
+		return false
+	}
+	for _, attr := range self.attrs {
+		if !attr.match(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+func (self cssAttr) match(elem *Elem) bool {
+	for _, attr := range elem.Attrs {
+		if attr.Name.Local != self.local {
+			continue
+		}
+		switch self.op {
+		case 0:
+			return true
+		case '=':
+			return attr.Value == self.value
+		case '~':
+			for _, word := range strings.Fields(attr.Value) {
+				if word == self.value {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+func parseSelector(sel string) (cssSelector, error) {
+	var out cssSelector
+	rest := strings.Trim(sel, whitespace)
+	if rest == "" {
+		return out, fmt.Errorf(`invalid CSS selector %q: empty selector`, sel)
+	}
+
+	for {
+		compound, next, err := parseCompound(rest)
+		if err != nil {
+			return out, fmt.Errorf(`invalid CSS selector %q: %w`, sel, err)
+		}
+		out.compounds = append(out.compounds, compound)
+
+		rest = strings.TrimLeft(next, whitespace)
+		if rest == "" {
+			return out, nil
+		}
+
+		if rest[0] == '>' {
+			out.combinators = append(out.combinators, '>')
+			rest = strings.TrimLeft(rest[1:], whitespace)
+		} else if len(rest) < len(next) {
+			out.combinators = append(out.combinators, ' ')
+		} else {
+			return out, fmt.Errorf(`invalid CSS selector %q: unexpected %q`, sel, rest[:1])
+		}
+	}
+}
+
+func parseCompound(src string) (cssCompound, string, error) {
+	var out cssCompound
+	rest := src
+	if rest == "" {
+		return out, "", fmt.Errorf(`unexpected end of selector`)
+	}
+
+	if strings.HasPrefix(rest, `*`) {
+		rest = rest[1:]
+	} else if name, next := cutCSSName(rest); name != "" {
+		if !isName(name) {
+			return out, "", fmt.Errorf(`invalid name %q`, name)
+		}
+		out.local, rest = name, next
+	}
+
+	for rest != "" {
+		var attr cssAttr
+		var err error
+
+		switch rest[0] {
+		case '[':
+			attr, rest, err = parseCSSAttr(rest)
+		case '.', '#':
+			delim := rest[0]
+			attr.op, attr.local = '~', `class`
+			if delim == '#' {
+				attr.op, attr.local = '=', `id`
+			}
+			attr.value, rest = cutCSSName(rest[1:])
+			if attr.value == "" {
+				err = fmt.Errorf(`missing name after %q`, delim)
+			}
+		default:
+			if len(rest) == len(src) {
+				return out, "", fmt.Errorf(`unexpected %q`, rest[:1])
+			}
+			return out, rest, nil
+		}
+
+		if err != nil {
+			return out, "", err
+		}
+		out.attrs = append(out.attrs, attr)
+	}
+
+	return out, rest, nil
+}
+
+// Parses `[name]` or `[name=value]` with an optionally quoted value.
+func parseCSSAttr(src string) (cssAttr, string, error) {
+	var out cssAttr
+
+	rest := strings.TrimLeft(src[1:], whitespace)
+	out.local, rest = cutCSSName(rest)
+	if !isName(out.local) {
+		return out, "", fmt.Errorf(`invalid attribute name %q`, out.local)
+	}
+	rest = strings.TrimLeft(rest, whitespace)
+
+	if strings.HasPrefix(rest, `=`) {
+		out.op = '='
+		rest = strings.TrimLeft(rest[1:], whitespace)
+
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				return out, "", fmt.Errorf(`unterminated attribute value`)
+			}
+			out.value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			out.value, rest = cutCSSName(rest)
+		}
+		rest = strings.TrimLeft(rest, whitespace)
+	}
+
+	if !strings.HasPrefix(rest, `]`) {
+		return out, "", fmt.Errorf(`unterminated attribute selector`)
+	}
+	return out, rest[1:], nil
+}
+
+// Splits off the leading name, up to the next delimiter.
+func cutCSSName(src string) (string, string) {
+	ind := strings.IndexAny(src, " \t\r\n>[].#=\"'*")
+	if ind < 0 {
+		ind = len(src)
+	}
+	return src[:ind], src[ind:]
+}
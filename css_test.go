@@ -0,0 +1,60 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySelector(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<html><body>
+  <div id="main" class="one two"><span class="foo">1</span><p><span class="foo">2</span></p></div>
+  <div><span>3</span><span class="foo bar" data-x="a b">4</span></div>
+</body></html>`))
+	require.NoError(t, err)
+	root := doc[0].(Elem)
+
+	texts := func(sel string) []string {
+		elems, err := root.QuerySelectorAll(sel)
+		require.NoError(t, err, sel)
+		var out []string
+		for _, elem := range elems {
+			out = append(out, elem.TextContent())
+		}
+		return out
+	}
+
+	require.Equal(t, []string{`1`, `2`, `3`, `4`}, texts(`span`))
+	require.Equal(t, []string{`1`, `4`}, texts(`div > span[class]`))
+	require.Equal(t, []string{`1`}, texts(`div > span[class="foo"]`))
+	require.Equal(t, []string{`1`, `2`, `4`}, texts(`div span.foo`))
+	require.Equal(t, []string{`1`, `2`}, texts(`#main span`))
+	require.Equal(t, []string{`2`}, texts(`div.two > * > span`))
+	require.Equal(t, []string{`4`}, texts(`[data-x='a b']`))
+	require.Equal(t, []string{`4`}, texts(`span.bar.foo`))
+	require.Equal(t, []string{`1`, `2`, `3`, `4`}, texts(`html span`))
+	require.Equal(t, []string{`1`}, texts(`body>div>span[ class = foo ]`))
+	require.Nil(t, texts(`html`))
+	require.Nil(t, texts(`p > p`))
+
+	elem, err := root.QuerySelector(`div span`)
+	require.NoError(t, err)
+	require.Equal(t, `1`, elem.TextContent())
+
+	elem, err = root.QuerySelector(`table`)
+	require.NoError(t, err)
+	require.Nil(t, elem)
+
+	for sel, msg := range map[string]string{
+		``:             `invalid CSS selector "": empty selector`,
+		`div >`:        `invalid CSS selector "div >": unexpected end of selector`,
+		`> div`:        `invalid CSS selector "> div": unexpected ">"`,
+		`div, span`:    `invalid CSS selector "div, span": invalid name "div,"`,
+		`[class`:       `invalid CSS selector "[class": unterminated attribute selector`,
+		`[class="foo]`: `invalid CSS selector "[class=\"foo]": unterminated attribute value`,
+		`div.`:         `invalid CSS selector "div.": missing name after '.'`,
+	} {
+		_, err := root.QuerySelector(sel)
+		require.EqualError(t, err, msg, sel)
+	}
+}
@@ -0,0 +1,160 @@
+package xt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Encoding names reported by `DetectEncoding`.
+const (
+	EncodingUTF8    = `UTF-8`
+	EncodingUTF16BE = `UTF-16BE`
+	EncodingUTF16LE = `UTF-16LE`
+	EncodingUTF32BE = `UTF-32BE`
+	EncodingUTF32LE = `UTF-32LE`
+)
+
+/*
+Reports the character encoding of an XML document without decoding it, by
+inspecting the byte order mark and the "encoding" pseudo-attribute of the XML
+declaration, following appendix F of the XML spec. This allows to choose a
+decoder up front.
+
+For documents in UTF-16 or UTF-32, with or without a BOM, returns one of the
+`Encoding` constants, since the byte order is only known from the input. For
+other documents, returns the declared encoding as-is, such as "ISO-8859-1",
+defaulting to "UTF-8". Returns an error if the declared encoding contradicts
+the BOM or the byte layout.
+*/
+func DetectEncoding(src []byte) (string, error) {
+	layout, bomSize := detectLayout(src)
+	declared := declEncoding(layout.ascii(src[bomSize:]))
+
+	if layout.name == EncodingUTF8 && bomSize == 0 {
+		if declared == "" {
+			return EncodingUTF8, nil
+		}
+		return declared, nil
+	}
+
+	if declared != "" && !layout.accepts(declared) {
+		return "", fmt.Errorf(`XML declaration specifies encoding %q, but the input is in %v`, declared, layout.name)
+	}
+	return layout.name, nil
+}
+
+/*
+Byte layout of an encoding: the width of code units, and whether they're
+big-endian.
+*/
+type encodingLayout struct {
+	name      string
+	width     int
+	bigEndian bool
+}
+
+var (
+	layoutUTF8    = encodingLayout{EncodingUTF8, 1, false}
+	layoutUTF16BE = encodingLayout{EncodingUTF16BE, 2, true}
+	layoutUTF16LE = encodingLayout{EncodingUTF16LE, 2, false}
+	layoutUTF32BE = encodingLayout{EncodingUTF32BE, 4, true}
+	layoutUTF32LE = encodingLayout{EncodingUTF32LE, 4, false}
+)
+
+/*
+Detects the layout from the BOM or, without one, from the first characters,
+which in a well-formed document must be "<?xml" or another "<". Also returns
+the size of the BOM.
+*/
+func detectLayout(src []byte) (encodingLayout, int) {
+	type signature struct {
+		prefix string
+		layout encodingLayout
+		bom    bool
+	}
+
+	// Order matters: UTF-32LE BOM starts with UTF-16LE BOM.
+	for _, sig := range []signature{
+		{"\x00\x00\xfe\xff", layoutUTF32BE, true},
+		{"\xff\xfe\x00\x00", layoutUTF32LE, true},
+		{"\xfe\xff", layoutUTF16BE, true},
+		{"\xff\xfe", layoutUTF16LE, true},
+		{"\xef\xbb\xbf", layoutUTF8, true},
+		{"\x00\x00\x00\x3c", layoutUTF32BE, false},
+		{"\x3c\x00\x00\x00", layoutUTF32LE, false},
+		{"\x00\x3c\x00\x3f", layoutUTF16BE, false},
+		{"\x3c\x00\x3f\x00", layoutUTF16LE, false},
+	} {
+		if bytes.HasPrefix(src, []byte(sig.prefix)) {
+			if sig.bom {
+				return sig.layout, len(sig.prefix)
+			}
+			return sig.layout, 0
+		}
+	}
+	return layoutUTF8, 0
+}
+
+/*
+Extracts the leading ASCII characters of the input, which are enough to read
+the XML declaration, stopping at the first non-ASCII character or at the end
+of the declaration.
+*/
+func (self encodingLayout) ascii(src []byte) string {
+	var buf strings.Builder
+
+	for ind := 0; ind+self.width <= len(src); ind += self.width {
+		unit := src[ind : ind+self.width]
+
+		var char byte
+		for pos, val := range unit {
+			isLow := pos == 0 && !self.bigEndian || pos == len(unit)-1 && self.bigEndian
+			if isLow {
+				char = val
+			} else if val != 0 {
+				return buf.String()
+			}
+		}
+		if char >= 0x80 {
+			break
+		}
+
+		buf.WriteByte(char)
+		if strings.HasSuffix(buf.String(), `?>`) {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// True if the declared encoding is compatible with this layout.
+func (self encodingLayout) accepts(declared string) bool {
+	names := []string{self.name}
+	switch self.width {
+	case 2:
+		names = append(names, `UTF-16`)
+	case 4:
+		names = append(names, `UTF-32`, `UCS-4`)
+	}
+
+	for _, name := range names {
+		if strings.EqualFold(declared, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the declared encoding from the XML declaration at the start.
+func declEncoding(src string) string {
+	if !strings.HasPrefix(src, `<?xml`) {
+		return ""
+	}
+	end := strings.Index(src, `?>`)
+	if end < 0 {
+		return ""
+	}
+	_, val := declPseudoAttr(src[:end], `encoding`)
+	return val
+}
@@ -0,0 +1,60 @@
+package xt
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	test := func(src []byte, expected string) {
+		t.Helper()
+		out, err := DetectEncoding(src)
+		require.NoError(t, err)
+		require.Equal(t, expected, out)
+	}
+
+	test(nil, EncodingUTF8)
+	test([]byte(`<one/>`), EncodingUTF8)
+	test([]byte(`<?xml version="1.0"?><one/>`), EncodingUTF8)
+	test([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><one/>`), `ISO-8859-1`)
+	test([]byte(`<?xml version='1.0' encoding = 'windows-1251' ?>`), `windows-1251`)
+	test([]byte("\xef\xbb\xbf<one/>"), EncodingUTF8)
+	test([]byte("\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"utf-8\"?>"), EncodingUTF8)
+
+	decl := `<?xml version="1.0" encoding="UTF-16"?><one/>`
+	test(append([]byte("\xfe\xff"), encodeUTF16(decl, true)...), EncodingUTF16BE)
+	test(append([]byte("\xff\xfe"), encodeUTF16(decl, false)...), EncodingUTF16LE)
+	test(encodeUTF16(decl, true), EncodingUTF16BE)
+	test(encodeUTF16(decl, false), EncodingUTF16LE)
+	test(append([]byte("\xff\xfe"), encodeUTF16(`<one/>`, false)...), EncodingUTF16LE)
+
+	test([]byte("\x00\x00\xfe\xff\x00\x00\x00<"), EncodingUTF32BE)
+	test([]byte("\xff\xfe\x00\x00<\x00\x00\x00"), EncodingUTF32LE)
+	test([]byte("<\x00\x00\x00?\x00\x00\x00"), EncodingUTF32LE)
+}
+
+func TestDetectEncodingMismatch(t *testing.T) {
+	_, err := DetectEncoding([]byte("\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>"))
+	require.EqualError(t, err, `XML declaration specifies encoding "ISO-8859-1", but the input is in UTF-8`)
+
+	_, err = DetectEncoding(append([]byte("\xff\xfe"), encodeUTF16(`<?xml version="1.0" encoding="UTF-16BE"?>`, false)...))
+	require.EqualError(t, err, `XML declaration specifies encoding "UTF-16BE", but the input is in UTF-16LE`)
+}
+
+func encodeUTF16(src string, bigEndian bool) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	var out []byte
+	for _, unit := range utf16.Encode([]rune(src)) {
+		buf := make([]byte, 2)
+		order.PutUint16(buf, unit)
+		out = append(out, buf...)
+	}
+	return out
+}
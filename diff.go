@@ -0,0 +1,178 @@
+package xt
+
+import "reflect"
+
+/*
+Kind of a `Change`. The zero value is invalid.
+*/
+type ChangeKind byte
+
+const (
+	ChangeInsert ChangeKind = iota + 1
+	ChangeRemove
+	ChangeReplace
+	ChangeAttrs
+)
+
+func (self ChangeKind) String() string {
+	switch self {
+	case ChangeInsert:
+		return "insert"
+	case ChangeRemove:
+		return "remove"
+	case ChangeReplace:
+		return "replace"
+	case ChangeAttrs:
+		return "attrs"
+	}
+	return "invalid"
+}
+
+/*
+Single edit produced by `Diff`. Only the fields relevant to the change's kind
+are set:
+
+	ChangeInsert   Path, New: `New` is inserted at `Path`
+	ChangeRemove   Path, Old: `Old` is removed from `Path`
+	ChangeReplace  Path, Old, New: `Old` at `Path` is replaced with `New`
+	ChangeAttrs    Path, Old, New: the attributes of the element at `Path`
+	               change from those of `Old` to those of `New`
+
+For `ChangeAttrs`, `Old` and `New` are elements without child nodes; changes
+to child nodes are reported separately. Other nodes are shared with the inputs
+of `Diff`.
+*/
+type Change struct {
+	Kind ChangeKind
+	Path Path
+	Old  Node
+	New  Node
+}
+
+/*
+Computes the changes which turn `a` into `b`. Child nodes are aligned via the
+longest common subsequence, where elements match elements with the same name,
+and other nodes match nodes of the same type. Matched elements are compared
+recursively, reporting their attributes via `ChangeAttrs` and their child
+nodes via further changes. Matched nodes of other types are reported via
+`ChangeReplace` when different. Unmatched nodes are reported via
+`ChangeRemove` and `ChangeInsert`. `Elem` and `*Elem` are compared by value,
+and the distinction between nil and empty slices is ignored, like in `Equal`.
+
+Changes are in document order. The path of each change refers to the tree as
+modified by the preceding changes, which accounts for shifted indexes:
+
+	Diff(
+		Nodes{E(`a`), E(`b`)},
+		Nodes{E(`b`), E(`c`)},
+	)
+	->
+	[]Change{
+		{Kind: ChangeRemove, Path: Path{0}, Old: E(`a`)},
+		{Kind: ChangeInsert, Path: Path{1}, New: E(`c`)},
+	}
+
+Returns nil when the inputs are equal. To apply the changes, use
+`Nodes.Apply`.
+*/
+func Diff(a, b Nodes) []Change {
+	var out []Change
+	diffNodes(&out, nil, a, b)
+	return out
+}
+
+func diffNodes(out *[]Change, path Path, a, b Nodes) {
+	keysA := diffKeys(a)
+	keysB := diffKeys(b)
+
+	// `lengths[indA][indB]` is the length of the longest common subsequence of
+	// `keysA[indA:]` and `keysB[indB:]`.
+	lengths := make([][]int, len(a)+1)
+	for ind := range lengths {
+		lengths[ind] = make([]int, len(b)+1)
+	}
+	for indA := len(a) - 1; indA >= 0; indA-- {
+		for indB := len(b) - 1; indB >= 0; indB-- {
+			if keysA[indA] == keysB[indB] {
+				lengths[indA][indB] = lengths[indA+1][indB+1] + 1
+			} else if lengths[indA+1][indB] >= lengths[indA][indB+1] {
+				lengths[indA][indB] = lengths[indA+1][indB]
+			} else {
+				lengths[indA][indB] = lengths[indA][indB+1]
+			}
+		}
+	}
+
+	// Index in the partially modified nodes.
+	pos := 0
+	indA, indB := 0, 0
+
+	for indA < len(a) || indB < len(b) {
+		switch {
+		case indA < len(a) && indB < len(b) && keysA[indA] == keysB[indB]:
+			diffNode(out, diffPath(path, pos), a[indA], b[indB])
+			pos++
+			indA++
+			indB++
+
+		case indA < len(a) && (indB == len(b) || lengths[indA+1][indB] >= lengths[indA][indB+1]):
+			*out = append(*out, Change{Kind: ChangeRemove, Path: diffPath(path, pos), Old: a[indA]})
+			indA++
+
+		default:
+			*out = append(*out, Change{Kind: ChangeInsert, Path: diffPath(path, pos), New: b[indB]})
+			pos++
+			indB++
+		}
+	}
+}
+
+// Compares nodes with the same key.
+func diffNode(out *[]Change, path Path, a, b Node) {
+	elemA, ok := nodeElem(a)
+	if !ok {
+		if !(EqualOptions{RespectPrefixes: true}).node(a, b) {
+			*out = append(*out, Change{Kind: ChangeReplace, Path: path, Old: a, New: b})
+		}
+		return
+	}
+	elemB, _ := nodeElem(b)
+
+	if !(EqualOptions{RespectPrefixes: true}).attrs(elemA.Attrs, elemB.Attrs) {
+		*out = append(*out, Change{
+			Kind: ChangeAttrs,
+			Path: path,
+			Old:  Elem{Name: elemA.Name, Prefix: elemA.Prefix, Attrs: elemA.Attrs},
+			New:  Elem{Name: elemB.Name, Prefix: elemB.Prefix, Attrs: elemB.Attrs},
+		})
+	}
+	diffNodes(out, path, elemA.Nodes, elemB.Nodes)
+}
+
+/*
+Identifies nodes which may be matched with each other: elements by name, and
+other nodes by type.
+*/
+type diffKey struct {
+	typ  reflect.Type
+	name Name
+}
+
+var elemType = reflect.TypeOf(Elem{})
+
+func diffKeys(nodes Nodes) []diffKey {
+	out := make([]diffKey, len(nodes))
+	for ind, node := range nodes {
+		elem, ok := nodeElem(node)
+		if ok {
+			out[ind] = diffKey{elemType, elem.Name}
+		} else {
+			out[ind] = diffKey{typ: reflect.TypeOf(node)}
+		}
+	}
+	return out
+}
+
+func diffPath(path Path, ind int) Path {
+	return append(path.clone(), ind)
+}
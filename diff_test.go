@@ -0,0 +1,57 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	a := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0"`},
+		Elem{
+			Name:  Name{Local: `config`},
+			Attrs: []Attr{{Name{Local: `version`}, `1`}},
+			Nodes: Nodes{
+				E(`a`),
+				E(`b`).C(Text(`one`)),
+				Comment(`note`),
+			},
+		},
+	}
+
+	b := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0"`},
+		E(`config`).A(`version`, `2`).C(
+			E(`b`).C(Text(`two`)),
+			Comment(`note`),
+			E(`c`),
+		),
+	}
+
+	require.Equal(t, []Change{
+		{
+			Kind: ChangeAttrs,
+			Path: Path{1},
+			Old:  Elem{Name: Name{Local: `config`}, Attrs: []Attr{{Name{Local: `version`}, `1`}}},
+			New:  Elem{Name: Name{Local: `config`}, Attrs: []Attr{{Name{Local: `version`}, `2`}}},
+		},
+		{Kind: ChangeRemove, Path: Path{1, 0}, Old: E(`a`)},
+		{Kind: ChangeReplace, Path: Path{1, 0, 0}, Old: Text(`one`), New: Text(`two`)},
+		{Kind: ChangeInsert, Path: Path{1, 2}, New: E(`c`)},
+	}, Diff(a, b))
+
+	require.Nil(t, Diff(a, a.Clone()))
+	require.Nil(t, Diff(Nodes{E(`a`)}, Nodes{Elem{Name: Name{Local: `a`}, Attrs: []Attr{}}}))
+
+	require.Equal(t, []Change{
+		{Kind: ChangeRemove, Path: Path{0}, Old: E(`a`)},
+		{Kind: ChangeInsert, Path: Path{0}, New: Text(`a`)},
+	}, Diff(Nodes{E(`a`)}, Nodes{Text(`a`)}))
+}
+
+func TestChangeKindString(t *testing.T) {
+	require.Equal(t, `insert`, ChangeInsert.String())
+	require.Equal(t, `attrs`, ChangeAttrs.String())
+	require.Equal(t, `invalid`, ChangeKind(0).String())
+}
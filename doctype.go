@@ -0,0 +1,238 @@
+package xt
+
+import "strings"
+
+/*
+Parses the declaration as a document type declaration, such as
+`DOCTYPE one SYSTEM "two" [<!ENTITY three "four">]`, reporting whether it is
+one. Returns false for other declarations and for malformed doctypes.
+*/
+func (self Decl) Doctype() (Doctype, bool) {
+	var out Doctype
+
+	rest := string(self)
+	if !strings.HasPrefix(rest, `DOCTYPE`) {
+		return out, false
+	}
+	rest = rest[len(`DOCTYPE`):]
+	if rest == "" || !strings.ContainsRune(whitespace, rune(rest[0])) {
+		return out, false
+	}
+
+	out.Name, rest = cutDoctypeName(strings.TrimLeft(rest, whitespace))
+	if !isName(out.Name) {
+		return out, false
+	}
+
+	var ok bool
+	out.PublicID, out.SystemID, rest, ok = cutExternalID(strings.TrimLeft(rest, whitespace))
+	if !ok {
+		return out, false
+	}
+	rest = strings.TrimLeft(rest, whitespace)
+
+	if strings.HasPrefix(rest, `[`) {
+		end := strings.LastIndexByte(rest, ']')
+		if end < 0 {
+			return out, false
+		}
+		out.InternalSubset = rest[1:end]
+		rest = strings.TrimLeft(rest[end+1:], whitespace)
+	}
+
+	return out, rest == ""
+}
+
+/*
+Returns the equivalent declaration, such as
+`DOCTYPE one PUBLIC "two" "three" [<!ENTITY four "five">]`. The public ID is
+only written together with the system ID, as required by XML. Identifiers are
+quoted with double quotes, or single quotes if they contain double quotes.
+*/
+func (self Doctype) Decl() Decl {
+	var buf strings.Builder
+	buf.WriteString(`DOCTYPE `)
+	buf.WriteString(self.Name)
+
+	if self.PublicID != "" {
+		buf.WriteString(` PUBLIC `)
+		writeDoctypeLiteral(&buf, self.PublicID)
+		buf.WriteString(` `)
+		writeDoctypeLiteral(&buf, self.SystemID)
+	} else if self.SystemID != "" {
+		buf.WriteString(` SYSTEM `)
+		writeDoctypeLiteral(&buf, self.SystemID)
+	}
+
+	if self.InternalSubset != "" {
+		buf.WriteString(` [`)
+		buf.WriteString(self.InternalSubset)
+		buf.WriteString(`]`)
+	}
+	return Decl(buf.String())
+}
+
+/*
+Entity declaration in the internal subset of a `Doctype`, as returned by
+`Doctype.Entities`.
+*/
+type EntityDecl struct {
+	/**
+	Name of the entity, such as "copy" for `<!ENTITY copy "&#169;">`.
+	*/
+	Name string
+
+	/**
+	True for parameter entities, such as `<!ENTITY % one "two">`, which are
+	referenced as `%one;` in the DTD rather than in the document.
+	*/
+	Parameter bool
+
+	/**
+	Replacement text of an internal entity, as written in the declaration,
+	without expanding character or entity references.
+	*/
+	Value string
+
+	/**
+	External identifiers of an external entity.
+	*/
+	PublicID string
+	SystemID string
+
+	/**
+	Notation of an unparsed external entity, such as "gif" for
+	`<!ENTITY one SYSTEM "one.gif" NDATA gif>`.
+	*/
+	Notation string
+}
+
+/*
+Parses the entity declarations in the internal subset, in order of
+appearance, skipping comments, processing instructions, other declarations,
+and malformed entity declarations. Doesn't read external DTDs.
+*/
+func (self Doctype) Entities() []EntityDecl {
+	var out []EntityDecl
+	rest := self.InternalSubset
+
+	for {
+		ind := strings.IndexByte(rest, '<')
+		if ind < 0 {
+			return out
+		}
+		rest = rest[ind:]
+
+		switch {
+		case strings.HasPrefix(rest, `<!--`):
+			rest = skipPast(rest, `-->`)
+		case strings.HasPrefix(rest, `<?`):
+			rest = skipPast(rest, `?>`)
+		case strings.HasPrefix(rest, `<!ENTITY`):
+			decl, ok := parseEntityDecl(rest[len(`<!ENTITY`):])
+			if ok {
+				out = append(out, decl)
+			}
+			rest = rest[tagLen([]byte(rest)):]
+		default:
+			rest = rest[tagLen([]byte(rest)):]
+		}
+	}
+}
+
+// Parses the rest of `<!ENTITY` up to and excluding ">".
+func parseEntityDecl(src string) (EntityDecl, bool) {
+	var out EntityDecl
+
+	rest := strings.TrimLeft(src, whitespace)
+	if len(rest) == len(src) {
+		return out, false
+	}
+	if strings.HasPrefix(rest, `%`) {
+		out.Parameter = true
+		rest = strings.TrimLeft(rest[1:], whitespace)
+	}
+
+	out.Name, rest = cutDoctypeName(rest)
+	if !isName(out.Name) {
+		return out, false
+	}
+	rest = strings.TrimLeft(rest, whitespace)
+
+	if value, next, ok := cutDoctypeLiteral(rest); ok {
+		out.Value, rest = value, next
+	} else {
+		var next string
+		out.PublicID, out.SystemID, next, ok = cutExternalID(rest)
+		if !ok || len(next) == len(rest) {
+			return out, false
+		}
+		rest = strings.TrimLeft(next, whitespace)
+
+		if !out.Parameter && strings.HasPrefix(rest, `NDATA`) {
+			out.Notation, rest = cutDoctypeName(strings.TrimLeft(rest[len(`NDATA`):], whitespace))
+		}
+	}
+
+	return out, strings.HasPrefix(strings.TrimLeft(rest, whitespace), `>`)
+}
+
+/*
+Parses an optional external ID such as `SYSTEM "one"` or `PUBLIC "one" "two"`.
+When absent, returns the input unchanged. Returns false if malformed.
+*/
+func cutExternalID(src string) (public, system, rest string, ok bool) {
+	switch {
+	case strings.HasPrefix(src, `PUBLIC`):
+		public, rest, ok = cutDoctypeLiteral(strings.TrimLeft(src[len(`PUBLIC`):], whitespace))
+		if !ok {
+			return
+		}
+		system, rest, ok = cutDoctypeLiteral(strings.TrimLeft(rest, whitespace))
+		return
+
+	case strings.HasPrefix(src, `SYSTEM`):
+		system, rest, ok = cutDoctypeLiteral(strings.TrimLeft(src[len(`SYSTEM`):], whitespace))
+		return
+	}
+	return "", "", src, true
+}
+
+func cutDoctypeName(src string) (string, string) {
+	ind := strings.IndexAny(src, whitespace+`[>"'`)
+	if ind < 0 {
+		return src, ""
+	}
+	return src[:ind], src[ind:]
+}
+
+func cutDoctypeLiteral(src string) (string, string, bool) {
+	if src == "" || (src[0] != '"' && src[0] != '\'') {
+		return "", src, false
+	}
+	end := strings.IndexByte(src[1:], src[0])
+	if end < 0 {
+		return "", src, false
+	}
+	return src[1 : end+1], src[end+2:], true
+}
+
+func writeDoctypeLiteral(buf *strings.Builder, val string) {
+	quote := `"`
+	if strings.Contains(val, `"`) {
+		quote = `'`
+	}
+	buf.WriteString(quote)
+	buf.WriteString(val)
+	buf.WriteString(quote)
+}
+
+// Returns the remainder after the first occurrence of the delimiter, or an
+// empty string if not found.
+func skipPast(src, delim string) string {
+	ind := strings.Index(src, delim)
+	if ind < 0 {
+		return ""
+	}
+	return src[ind+len(delim):]
+}
@@ -0,0 +1,94 @@
+package xt
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeDoctype(t *testing.T) {
+	src := []byte(`<!DOCTYPE one PUBLIC "-//two//EN" 'three.dtd' [
+  <!ENTITY copy "&#169;">
+  <!ENTITY % four SYSTEM "four.ent">
+  <!ENTITY logo PUBLIC "five" "logo.gif" NDATA gif>
+  <!ELEMENT one (#PCDATA)>
+  <?six seven?>
+  <!ENTITY broken>
+]><one/>`)
+
+	doc, err := Parser{DecodeDoctype: true}.Parse(src)
+	require.NoError(t, err)
+
+	doctype := Doctype{
+		Name:     `one`,
+		PublicID: `-//two//EN`,
+		SystemID: `three.dtd`,
+		InternalSubset: `
+  <!ENTITY copy "&#169;">
+  <!ENTITY % four SYSTEM "four.ent">
+  <!ENTITY logo PUBLIC "five" "logo.gif" NDATA gif>
+  <!ELEMENT one (#PCDATA)>
+  <?six seven?>
+  <!ENTITY broken>
+`,
+	}
+	require.Equal(t, doctype, doc[0])
+
+	require.Equal(t, []EntityDecl{
+		{Name: `copy`, Value: `&#169;`},
+		{Name: `four`, Parameter: true, SystemID: `four.ent`},
+		{Name: `logo`, PublicID: `five`, SystemID: `logo.gif`, Notation: `gif`},
+	}, doctype.Entities())
+
+	plain, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, Decl(`DOCTYPE one PUBLIC "-//two//EN" "three.dtd" [`+doctype.InternalSubset+`]`), doctype.Decl())
+	require.IsType(t, Decl(``), plain[0])
+
+	converted, ok := plain[0].(Decl).Doctype()
+	require.True(t, ok)
+	require.Equal(t, doctype, converted)
+
+	out, err := xml.Marshal(Nodes{doctype})
+	require.NoError(t, err)
+	require.Equal(t, `<!`+string(doctype.Decl())+`>`, string(out))
+
+	out, err = MarshalOptions{}.Marshal(Nodes{doctype})
+	require.NoError(t, err)
+	require.Equal(t, `<!`+string(doctype.Decl())+`>`, string(out))
+
+	out, err = json.Marshal(Doctype{Name: `html`, SystemID: `about:legacy-compat`})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "doctype", "name": "html", "systemId": "about:legacy-compat"}`, string(out))
+
+	node, err := UnmarshalNodeJSON(out)
+	require.NoError(t, err)
+	require.Equal(t, Doctype{Name: `html`, SystemID: `about:legacy-compat`}, node)
+
+	_, err = xml.Marshal(Doctype{})
+	require.EqualError(t, err, `can't encode XML doctype with empty name`)
+}
+
+func TestDeclDoctype(t *testing.T) {
+	for src, expected := range map[Decl]Doctype{
+		`DOCTYPE html`:                     {Name: `html`},
+		"DOCTYPE\thtml SYSTEM 'a\"b' ":     {Name: `html`, SystemID: `a"b`},
+		`DOCTYPE one[<!ENTITY two "]">]`:   {Name: `one`, InternalSubset: `<!ENTITY two "]">`},
+		`DOCTYPE one PUBLIC "two" "three"`: {Name: `one`, PublicID: `two`, SystemID: `three`},
+	} {
+		doctype, ok := src.Doctype()
+		require.True(t, ok, src)
+		require.Equal(t, expected, doctype, src)
+
+		again, ok := doctype.Decl().Doctype()
+		require.True(t, ok, src)
+		require.Equal(t, expected, again, src)
+	}
+
+	for _, src := range []Decl{``, `DOCTYPE`, `DOCTYPEhtml`, `ELEMENT one ANY`, `DOCTYPE one PUBLIC "two"`, `DOCTYPE one SYSTEM two`, `DOCTYPE one [`, `DOCTYPE one two`} {
+		_, ok := src.Doctype()
+		require.False(t, ok, src)
+	}
+}
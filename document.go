@@ -0,0 +1,283 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Content of the XML declaration added by `Elem.AsDocument`.
+const defaultXMLDecl = `version="1.0" encoding="utf-8"`
+
+/*
+Returns a standalone document consisting of an XML declaration and a copy of
+the element. Useful for extracting a single record from a larger document.
+
+The optional `ancestors` are the element's ancestors, from the outermost to the
+immediate parent. Namespace declarations in scope from the ancestors are copied
+onto the resulting element when its subtree uses the corresponding namespaces,
+so that the extracted element remains valid on its own. Declarations already
+present on the element are left as-is. The original element is not modified.
+*/
+func (self Elem) AsDocument(ancestors ...*Elem) Nodes {
+	return Nodes{
+		Pi{Target: `xml`, Content: defaultXMLDecl},
+		Text("\n"),
+		self.withScope(ancestors),
+	}
+}
+
+/*
+Returns the XML declaration such as `<?xml version="1.0"?>`, if present. Only
+the first node is checked, because the declaration is only allowed at the very
+start of a document; a `Pi` with the target "xml" elsewhere is not a
+declaration, and is ignored.
+*/
+func (self Nodes) XMLDeclaration() (Pi, bool) {
+	if len(self) == 0 {
+		return Pi{}, false
+	}
+	pi, ok := self[0].(Pi)
+	return pi, ok && pi.Target == `xml`
+}
+
+/*
+Parses the content of an XML declaration, such as
+`version="1.0" encoding="UTF-8" standalone="yes"`, returning the values of its
+pseudo-attributes. Missing optional fields are empty. Returns false if the
+target is not "xml", or if the content is malformed: the pseudo-attributes
+must be known, unique, in the order required by XML, and include the version.
+Values are returned as written, without validation.
+*/
+func (self Pi) XMLDeclFields() (version, encoding, standalone string, ok bool) {
+	if self.Target != `xml` {
+		return
+	}
+
+	fields := [...]*string{&version, &encoding, &standalone}
+	names := [...]string{`version`, `encoding`, `standalone`}
+	next := 0
+	rest := strings.Trim(self.Content, whitespace)
+
+	for rest != "" {
+		ind := strings.IndexAny(rest, whitespace+`=`)
+		if ind < 0 {
+			return "", "", "", false
+		}
+		name := rest[:ind]
+
+		pos := next
+		for pos < len(names) && names[pos] != name {
+			pos++
+		}
+		if pos == len(names) {
+			return "", "", "", false
+		}
+
+		rest = strings.TrimLeft(rest[ind:], whitespace)
+		if !strings.HasPrefix(rest, `=`) {
+			return "", "", "", false
+		}
+
+		val, tail, valid := cutDoctypeLiteral(strings.TrimLeft(rest[1:], whitespace))
+		if !valid || (tail != "" && !strings.ContainsRune(whitespace, rune(tail[0]))) {
+			return "", "", "", false
+		}
+
+		*fields[pos] = val
+		next = pos + 1
+		rest = strings.TrimLeft(tail, whitespace)
+	}
+
+	if version == "" {
+		return "", "", "", false
+	}
+	return version, encoding, standalone, true
+}
+
+/*
+Returns the nodes without the leading XML declaration, if any. See
+`Nodes.XMLDeclaration`. Whitespace which followed the declaration is kept.
+The result shares memory with the original. Useful when merging fragments,
+or when the declaration is written separately.
+*/
+func (self Nodes) WithoutXMLDeclaration() Nodes {
+	_, ok := self.XMLDeclaration()
+	if ok {
+		return self[1:]
+	}
+	return self
+}
+
+/*
+Returns the root element of a document: the only element among the top-level
+nodes, ignoring others such as the XML declaration, whitespace and comments.
+Returns an error when there are no elements, or more than one, as in a
+fragment with multiple roots. The pointer is the same as for
+`Nodes.FirstElement`.
+*/
+func (self Nodes) Root() (*Elem, error) {
+	var out *Elem
+	count := 0
+
+	for _, node := range self {
+		elem, ok := nodeElem(node)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = elem
+		}
+		count++
+	}
+
+	if count != 1 {
+		return nil, fmt.Errorf(`expected exactly one root element, found %d`, count)
+	}
+	return out, nil
+}
+
+/*
+Encodes only the nodes matching the predicate, along with their subtrees, as
+an XML fragment. The tree is searched depth-first; descendants of a matching
+node are not tested. Namespace declarations required by the matching elements
+are copied from their ancestors, like in `Elem.AsDocument`.
+*/
+func (self Nodes) MarshalMatching(pred func(Node) bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	err := marshalMatching(enc, self, nil, pred)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Flush()
+	return buf.Bytes(), err
+}
+
+func marshalMatching(enc *xml.Encoder, nodes Nodes, ancestors []*Elem, pred func(Node) bool) error {
+	for _, node := range nodes {
+		elem, isElem := nodeElem(node)
+
+		if pred(node) {
+			if isElem {
+				node = elem.withScope(ancestors)
+			}
+			err := enc.Encode(node)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isElem {
+			err := marshalMatching(enc, elem.Nodes, append(ancestors, elem), pred)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Returns a copy of the element with namespace declarations from the ancestors
+that it depends on. See `Elem.AsDocument`.
+*/
+func (self Elem) withScope(ancestors []*Elem) Elem {
+	var decls []Attr
+	used := self.namespaces(nil)
+
+	for _, decl := range scopeDecls(ancestors) {
+		if !used[decl.Value] || self.declares(decl) {
+			continue
+		}
+		decls = append(decls, decl)
+	}
+
+	if decls != nil {
+		self.Attrs = append(decls, self.Attrs...)
+	}
+	return self
+}
+
+/*
+Returns the namespace declarations in scope for the descendants of the given
+elements, ordered from outermost to innermost. Declarations shadowed by later
+ones with the same prefix are omitted.
+*/
+func scopeDecls(ancestors []*Elem) []Attr {
+	var out []Attr
+
+	for _, elem := range ancestors {
+		for _, attr := range elem.Attrs {
+			prefix, _, ok := attr.DeclaredPrefix()
+			if !ok {
+				continue
+			}
+
+			for ind, prev := range out {
+				prevPrefix, _, _ := prev.DeclaredPrefix()
+				if prevPrefix == prefix {
+					out = append(out[:ind], out[ind+1:]...)
+					break
+				}
+			}
+			out = append(out, attr)
+		}
+	}
+
+	return out
+}
+
+// True if the element has a namespace declaration for the same prefix.
+func (self Elem) declares(decl Attr) bool {
+	prefix, _, _ := decl.DeclaredPrefix()
+	for _, attr := range self.Attrs {
+		other, _, ok := attr.DeclaredPrefix()
+		if ok && other == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Collects the namespace URIs used by the names of the element and its
+descendants, excluding the reserved namespaces.
+*/
+func (self Elem) namespaces(out map[string]bool) map[string]bool {
+	if out == nil {
+		out = map[string]bool{}
+	}
+
+	if self.Name.Space != "" {
+		out[self.Name.Space] = true
+	}
+	for _, attr := range self.Attrs {
+		space := attr.Name.Space
+		if space != "" && space != NamespaceXMLNS && space != NamespaceXML {
+			out[space] = true
+		}
+	}
+
+	namespacesIn(self.Nodes, out)
+	return out
+}
+
+func namespacesIn(nodes Nodes, out map[string]bool) {
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case Elem:
+			node.namespaces(out)
+		case *Elem:
+			if node != nil {
+				node.namespaces(out)
+			}
+		case Nodes:
+			namespacesIn(node, out)
+		}
+	}
+}
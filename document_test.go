@@ -0,0 +1,161 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsDocument(t *testing.T) {
+	root := expectedNsAliased[2].(Elem)
+	four := root.Nodes[1].(Elem)
+
+	doc := four.AsDocument(&root)
+
+	expected := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0" encoding="utf-8"`},
+		Text("\n"),
+		Elem{
+			Name: Name{Space: `ns_outer`, Local: `four`},
+			Attrs: []Attr{
+				{Name: Name{Space: `xmlns`, Local: `outer`}, Value: `ns_outer`},
+			},
+		},
+	}
+	require.Equal(t, expected, doc)
+	require.Equal(t, []Attr{}, four.Attrs, `must not modify the original`)
+
+	content, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="utf-8"?>
+<four xmlns="ns_outer" xmlns:outer="ns_outer"></four>`, string(content))
+
+	var decoded Nodes
+	require.NoError(t, decoded.Decode(xml.NewDecoder(bytes.NewReader(content))))
+	require.True(t, Equal(doc, decoded, EqualOptions{}))
+}
+
+func TestAsDocumentUnusedNamespace(t *testing.T) {
+	root := expectedNsInlined[2].(Elem)
+	five := root.Nodes[3].(Elem)
+
+	require.Equal(t, Nodes{Pi{Target: `xml`, Content: `version="1.0" encoding="utf-8"`}, Text("\n"), five}, five.AsDocument(&root))
+}
+
+func TestAsDocumentNestedNodes(t *testing.T) {
+	root := expectedNsAliased[2].(Elem)
+	child := Elem{Name: Name{Space: `ns_outer`, Local: `two`}}
+	elem := Elem{Name: Name{Local: `one`}, Nodes: Nodes{(*Elem)(nil), Nodes{child}}}
+
+	require.Equal(t, Nodes{
+		Pi{Target: `xml`, Content: `version="1.0" encoding="utf-8"`},
+		Text("\n"),
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{{Name: Name{Space: `xmlns`, Local: `outer`}, Value: `ns_outer`}},
+			Nodes: elem.Nodes,
+		},
+	}, elem.AsDocument(&root))
+}
+
+func TestXMLDeclaration(t *testing.T) {
+	decl := Pi{Target: `xml`, Content: `version="1.0"`}
+	elem := Elem{Name: Name{Local: `one`}}
+
+	pi, ok := Nodes{decl, Text("\n"), elem}.XMLDeclaration()
+	require.True(t, ok)
+	require.Equal(t, decl, pi)
+	require.Equal(t, Nodes{Text("\n"), elem}, Nodes{decl, Text("\n"), elem}.WithoutXMLDeclaration())
+
+	for _, nodes := range []Nodes{nil, {elem}, {Text("\n"), decl}, {Pi{Target: `xml-stylesheet`}}} {
+		_, ok = nodes.XMLDeclaration()
+		require.False(t, ok)
+		require.Equal(t, nodes, nodes.WithoutXMLDeclaration())
+	}
+}
+
+func TestXMLDeclFields(t *testing.T) {
+	test := func(content, version, encoding, standalone string) {
+		t.Helper()
+		outVersion, outEncoding, outStandalone, ok := Pi{Target: `xml`, Content: content}.XMLDeclFields()
+		require.True(t, ok)
+		require.Equal(t, version, outVersion)
+		require.Equal(t, encoding, outEncoding)
+		require.Equal(t, standalone, outStandalone)
+	}
+
+	test(`version="1.0"`, `1.0`, ``, ``)
+	test(`version="1.0" encoding="UTF-8" standalone="yes"`, `1.0`, `UTF-8`, `yes`)
+	test(" version = '1.1'\n\tstandalone='no' ", `1.1`, ``, `no`)
+	test(`version="1.0" encoding="windows-1251"`, `1.0`, `windows-1251`, ``)
+
+	for _, pi := range []Pi{
+		{Target: `xml-stylesheet`, Content: `version="1.0"`},
+		{Target: `xml`},
+		{Target: `xml`, Content: `encoding="UTF-8"`},
+		{Target: `xml`, Content: `encoding="UTF-8" version="1.0"`},
+		{Target: `xml`, Content: `version="1.0" version="1.0"`},
+		{Target: `xml`, Content: `version="1.0" other="one"`},
+		{Target: `xml`, Content: `version="1.0`},
+		{Target: `xml`, Content: `version=1.0`},
+		{Target: `xml`, Content: `version="1.0"encoding="UTF-8"`},
+		{Target: `xml`, Content: `version`},
+	} {
+		_, _, _, ok := pi.XMLDeclFields()
+		require.False(t, ok, pi.Content)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	root, err := expectedSimple.Root()
+	require.NoError(t, err)
+	require.Equal(t, `one`, root.Name.Local)
+
+	two := &Elem{Name: Name{Local: `two`}}
+	root, err = Nodes{Comment(`one`), two, Text("\n")}.Root()
+	require.NoError(t, err)
+	require.True(t, root == two)
+
+	_, err = Nodes{Pi{Target: `xml`}, Text("\n")}.Root()
+	require.EqualError(t, err, `expected exactly one root element, found 0`)
+
+	_, err = Nodes{*two, Text("\n"), two}.Root()
+	require.EqualError(t, err, `expected exactly one root element, found 2`)
+}
+
+func TestMarshalMatching(t *testing.T) {
+	isNine := func(node Node) bool {
+		elem, ok := node.(Elem)
+		return ok && elem.Name.Local == `nine`
+	}
+
+	content, err := expectedSimple.MarshalMatching(isNine)
+	require.NoError(t, err)
+	require.Equal(t, `<nine ten="eleven">
+      twelve
+      <!-- thirteen -->
+    </nine>`, string(content))
+
+	isNamespaced := func(node Node) bool {
+		elem, ok := node.(Elem)
+		return ok && elem.Name.Space != ``
+	}
+
+	content, err = expectedNsAliased[2].(Elem).Nodes.MarshalMatching(isNamespaced)
+	require.NoError(t, err)
+	require.Equal(t, `<four xmlns="ns_outer"></four><five xmlns="ns_inner" xmlns:inner="ns_inner" six="seven"></five>`, string(content))
+
+	doc, err := Parser{}.Parse(read(t, `ns_aliased.xml`))
+	require.NoError(t, err)
+
+	isFour := func(node Node) bool {
+		elem, ok := node.(Elem)
+		return ok && elem.Name.Local == `four`
+	}
+
+	content, err = doc.MarshalMatching(isFour)
+	require.NoError(t, err)
+	require.Equal(t, `<four xmlns="ns_outer" xmlns:outer="ns_outer"></four>`, string(content))
+}
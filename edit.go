@@ -0,0 +1,56 @@
+package xt
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+/*
+Inserts the node into the middle of the text node at `Nodes[childIdx]`,
+splitting the text at the given offset, counted in runes. For example, to
+insert `<b/>` before "world" in `<p>hello world</p>`:
+
+	elem.InsertAtTextOffset(0, 6, Elem{Name: Name{Local: "b"}})
+	// <p>hello <b></b>world</p>
+
+Inserting at the start or end of the text doesn't produce empty text nodes.
+Returns an error if the child doesn't exist, isn't `Text`, or the offset is
+out of range.
+*/
+func (self *Elem) InsertAtTextOffset(childIdx, runeOffset int, node Node) error {
+	if childIdx < 0 || childIdx >= len(self.Nodes) {
+		return fmt.Errorf(`child index %d out of range for <%s> with %d nodes`, childIdx, self.Name.clark(), len(self.Nodes))
+	}
+
+	text, ok := self.Nodes[childIdx].(Text)
+	if !ok {
+		return fmt.Errorf(`expected text node at index %d in <%s>, found %T`, childIdx, self.Name.clark(), self.Nodes[childIdx])
+	}
+
+	size := utf8.RuneCountInString(string(text))
+	if runeOffset < 0 || runeOffset > size {
+		return fmt.Errorf(`text offset %d out of range for text node of %d characters`, runeOffset, size)
+	}
+
+	byteOffset := 0
+	for ind := 0; ind < runeOffset; ind++ {
+		_, width := utf8.DecodeRuneInString(string(text[byteOffset:]))
+		byteOffset += width
+	}
+
+	var replacement Nodes
+	if byteOffset > 0 {
+		replacement = append(replacement, text[:byteOffset])
+	}
+	replacement = append(replacement, node)
+	if byteOffset < len(text) {
+		replacement = append(replacement, text[byteOffset:])
+	}
+
+	out := make(Nodes, 0, len(self.Nodes)+len(replacement)-1)
+	out = append(out, self.Nodes[:childIdx]...)
+	out = append(out, replacement...)
+	out = append(out, self.Nodes[childIdx+1:]...)
+	self.Nodes = out
+	return nil
+}
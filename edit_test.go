@@ -0,0 +1,44 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertAtTextOffset(t *testing.T) {
+	elem := Elem{Name: Name{Local: `p`}, Nodes: Nodes{Text(`hello world`), Comment(`one`)}}
+
+	require.NoError(t, elem.InsertAtTextOffset(0, 6, Elem{Name: Name{Local: `b`}}))
+	require.Equal(t, Nodes{Text(`hello `), Elem{Name: Name{Local: `b`}}, Text(`world`), Comment(`one`)}, elem.Nodes)
+
+	bold := elem.Nodes[1].(Elem)
+	bold.Nodes = Nodes{elem.Nodes[2]}
+	elem.Nodes = append(Nodes{elem.Nodes[0], bold}, elem.Nodes[3:]...)
+
+	out, err := MarshalOptions{}.Marshal(elem)
+	require.NoError(t, err)
+	require.Equal(t, `<p>hello <b>world</b><!--one--></p>`, string(out))
+}
+
+func TestInsertAtTextOffsetEdges(t *testing.T) {
+	elem := Elem{Nodes: Nodes{Text(`日本`)}}
+
+	require.NoError(t, elem.InsertAtTextOffset(0, 1, Comment(`one`)))
+	require.Equal(t, Nodes{Text(`日`), Comment(`one`), Text(`本`)}, elem.Nodes)
+
+	require.NoError(t, elem.InsertAtTextOffset(0, 0, Comment(`two`)))
+	require.Equal(t, Nodes{Comment(`two`), Text(`日`), Comment(`one`), Text(`本`)}, elem.Nodes)
+
+	require.NoError(t, elem.InsertAtTextOffset(3, 1, Comment(`three`)))
+	require.Equal(t, Nodes{Comment(`two`), Text(`日`), Comment(`one`), Text(`本`), Comment(`three`)}, elem.Nodes)
+}
+
+func TestInsertAtTextOffsetInvalid(t *testing.T) {
+	elem := Elem{Name: Name{Local: `p`}, Nodes: Nodes{Text(`one`), Comment(`two`)}}
+
+	require.EqualError(t, elem.InsertAtTextOffset(2, 0, Text(``)), `child index 2 out of range for <p> with 2 nodes`)
+	require.EqualError(t, elem.InsertAtTextOffset(1, 0, Text(``)), `expected text node at index 1 in <p>, found xt.Comment`)
+	require.EqualError(t, elem.InsertAtTextOffset(0, 4, Text(``)), `text offset 4 out of range for text node of 3 characters`)
+	require.EqualError(t, elem.InsertAtTextOffset(0, -1, Text(``)), `text offset -1 out of range for text node of 3 characters`)
+}
@@ -0,0 +1,199 @@
+package xt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Finds the first child element with the given name, and parses its text as a
+float, ignoring surrounding whitespace. Parsing is locale-independent, via
+`strconv`. Returns an error if the child is missing or its text is malformed.
+For example, for `<item><price> 12.50 </price></item>`,
+`ChildFloat(Name{Local: "price"})` returns 12.5.
+*/
+func (self Elem) ChildFloat(name Name) (float64, error) {
+	for _, node := range self.Nodes {
+		child, ok := nodeElem(node)
+		if ok && child.Name == name {
+			return parseFloat(child.Nodes.ownText())
+		}
+	}
+	return 0, fmt.Errorf(`missing child element <%s> in <%s>`, name.clark(), self.Name.clark())
+}
+
+/*
+Returns the value of the first attribute with the given namespace and local
+name, and whether it was found. Empty `space` denotes an attribute without a
+namespace; unlike `Elem.Find`, it doesn't match other namespaces.
+*/
+func (self *Elem) Attr(space, local string) (string, bool) {
+	return AttrList(self.Attrs).Get(space, local)
+}
+
+/*
+Sets the value of the first attribute with the given namespace and local name,
+updating it in-place, or appends a new attribute if there is none. Empty
+`space` denotes an attribute without a namespace.
+*/
+func (self *Elem) SetAttr(space, local, value string) {
+	(*AttrList)(&self.Attrs).Set(space, local, value)
+}
+
+/*
+Removes the first attribute with the given namespace and local name, keeping
+the order of the remaining attributes, and reports whether it was found. Empty
+`space` denotes an attribute without a namespace. Doesn't modify the original
+backing array, which may be shared with copies of the element. Removing the
+last attribute leaves an empty non-nil slice, matching freshly decoded
+elements.
+*/
+func (self *Elem) RemoveAttr(space, local string) bool {
+	return (*AttrList)(&self.Attrs).Delete(space, local)
+}
+
+/*
+Finds the first descendant element with the given namespace and local name,
+depth-first in document order, or nil if none is found. The element itself is
+not included. An empty `space` matches any namespace.
+
+For descendants stored as `*Elem`, returns the same pointer. For descendants
+stored as `Elem`, returns a pointer to a copy, which shares the `Attrs` and
+`Nodes` slices with the original. Replacing the fields of the copy doesn't
+affect the tree, but in-place edits of the shared slices do, including
+`Elem.SetAttr` on an existing attribute. To modify the result independently,
+copy it first via `Elem.Clone`.
+*/
+func (self *Elem) Find(space, local string) *Elem {
+	var out *Elem
+	_ = self.findAll(space, local, func(elem *Elem) error {
+		out = elem
+		return errFound
+	})
+	return out
+}
+
+/*
+Same as `Elem.Find`, but returns all matching descendants in document order.
+Returns nil if none are found.
+*/
+func (self *Elem) FindAll(space, local string) []*Elem {
+	var out []*Elem
+	_ = self.findAll(space, local, func(elem *Elem) error {
+		out = append(out, elem)
+		return nil
+	})
+	return out
+}
+
+func (self *Elem) findAll(space, local string, fn func(*Elem) error) error {
+	return Walk(self.Nodes, func(node Node) error {
+		elem, ok := nodeElem(node)
+		if ok && elem.Name.Local == local && (space == "" || elem.Name.Space == space) {
+			return fn(elem)
+		}
+		return nil
+	})
+}
+
+// Used to stop `Walk` early.
+var errFound = errors.New(`found`)
+
+func parseFloat(val string) (float64, error) {
+	return strconv.ParseFloat(strings.Trim(val, whitespace), 64)
+}
+
+/*
+Returns the text of the element's descendants, like `textContent` in the DOM:
+the concatenation of all `Text` and `CData` nodes in the subtree, in document
+order. Comments, processing instructions and declarations are skipped.
+*/
+func (self Elem) TextContent() string {
+	return self.Nodes.TextContent()
+}
+
+// Same as `Elem.TextContent`, but for a sequence of nodes.
+func (self Nodes) TextContent() string {
+	var buf strings.Builder
+	_ = Walk(self, func(node Node) error {
+		switch node := node.(type) {
+		case Text:
+			buf.WriteString(string(node))
+		case CData:
+			buf.WriteString(string(node))
+		}
+		return nil
+	})
+	return buf.String()
+}
+
+/*
+Returns the elements in the sequence, in order, skipping other nodes such as
+whitespace text, comments and processing instructions. Elements stored as
+`*Elem` are copied; nil pointers are skipped. Nested `Nodes` are not searched.
+Returns nil if there are no elements.
+*/
+func (self Nodes) Elements() []Elem {
+	var out []Elem
+	for _, node := range self {
+		elem, ok := nodeElem(node)
+		if ok {
+			out = append(out, *elem)
+		}
+	}
+	return out
+}
+
+// Returns the first node, if any.
+func (self Nodes) First() (Node, bool) {
+	if len(self) == 0 {
+		return nil, false
+	}
+	return self[0], true
+}
+
+/*
+Returns the first element in the sequence, skipping other nodes, like
+`Nodes.Elements`. For `*Elem`, returns the same pointer. For `Elem`, returns a
+pointer to a copy, which shares the `Attrs` and `Nodes` slices with the
+original.
+*/
+func (self Nodes) FirstElement() (*Elem, bool) {
+	for _, node := range self {
+		elem, ok := nodeElem(node)
+		if ok {
+			return elem, true
+		}
+	}
+	return nil, false
+}
+
+/*
+Concatenates the text and CDATA nodes in the sequence, ignoring nested
+elements.
+*/
+func (self Nodes) ownText() string {
+	var buf strings.Builder
+	for _, node := range self {
+		switch node := node.(type) {
+		case Text:
+			buf.WriteString(string(node))
+		case CData:
+			buf.WriteString(string(node))
+		}
+	}
+	return buf.String()
+}
+
+/*
+Formats the name in "Clark notation", with the namespace in braces:
+"{space}local". Names without a namespace are formatted as just "local".
+*/
+func (self Name) clark() string {
+	if self.Space == "" {
+		return self.Local
+	}
+	return `{` + self.Space + `}` + self.Local
+}
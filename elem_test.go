@@ -0,0 +1,146 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElemFloat(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<item><price> 12.50 </price><name>one</name></item>`))
+	require.NoError(t, err)
+	item := doc[0].(Elem)
+
+	val, err := item.ChildFloat(Name{Local: `price`})
+	require.NoError(t, err)
+	require.Equal(t, 12.5, val)
+
+	_, err = item.ChildFloat(Name{Local: `name`})
+	require.Error(t, err)
+
+	_, err = item.ChildFloat(Name{Local: `missing`})
+	require.EqualError(t, err, `missing child element <missing> in <item>`)
+}
+
+func TestElemFind(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<one><two id="1"><three id="2"/></two><three xmlns="four" id="3"/></one>`))
+	require.NoError(t, err)
+	one := doc[0].(Elem)
+
+	require.Nil(t, one.Find(``, `one`))
+	require.Nil(t, one.Find(``, `missing`))
+	require.Equal(t, Name{Local: `two`}, one.Find(``, `two`).Name)
+	require.Equal(t, `2`, one.Find(``, `three`).Attrs[0].Value)
+	require.Equal(t, Name{Space: `four`, Local: `three`}, one.Find(`four`, `three`).Name)
+
+	found := one.FindAll(``, `three`)
+	require.Len(t, found, 2)
+	require.Equal(t, `2`, found[0].Attrs[0].Value)
+	require.Equal(t, `four`, found[1].Name.Space)
+
+	require.Len(t, one.FindAll(`four`, `three`), 1)
+	require.Nil(t, one.FindAll(``, `missing`))
+
+	ptr := &Elem{Name: Name{Local: `five`}}
+	outer := Elem{Name: Name{Local: `six`}, Nodes: Nodes{ptr}}
+	require.True(t, outer.Find(``, `five`) == ptr)
+}
+
+func TestElemAttr(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<one two="three" xmlns:four="five" four:two="six"/>`))
+	require.NoError(t, err)
+	elem := doc[0].(Elem)
+
+	test := func(space, local, expected string, found bool) {
+		t.Helper()
+		val, ok := elem.Attr(space, local)
+		require.Equal(t, found, ok)
+		require.Equal(t, expected, val)
+	}
+
+	test(``, `two`, `three`, true)
+	test(`five`, `two`, `six`, true)
+	test(`four`, `two`, ``, false)
+	test(``, `missing`, ``, false)
+
+	elem.SetAttr(`five`, `two`, `seven`)
+	elem.SetAttr(``, `eight`, `nine`)
+	test(`five`, `two`, `seven`, true)
+	test(``, `eight`, `nine`, true)
+
+	out, err := MarshalOptions{}.Marshal(elem)
+	require.NoError(t, err)
+	require.Equal(t, `<one two="three" xmlns:four="five" four:two="seven" eight="nine"></one>`, string(out))
+
+	var empty Elem
+	empty.SetAttr(``, `one`, `two`)
+	require.Equal(t, []Attr{{Name: Name{Local: `one`}, Value: `two`}}, empty.Attrs)
+}
+
+func TestElemRemoveAttr(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<one xmlns="two" three="four" five="six" three="seven"/>`))
+	require.NoError(t, err)
+	elem := doc[0].(Elem)
+	orig := elem
+
+	require.False(t, elem.RemoveAttr(``, `missing`))
+	require.False(t, elem.RemoveAttr(`two`, `three`))
+	require.True(t, elem.RemoveAttr(``, `three`))
+	require.Equal(t, []Attr{
+		{Name: Name{Local: `xmlns`}, Value: `two`},
+		{Name: Name{Local: `five`}, Value: `six`},
+		{Name: Name{Local: `three`}, Value: `seven`},
+	}, elem.Attrs)
+	require.Equal(t, Name{Local: `three`}, orig.Attrs[1].Name, `must not modify the original array`)
+
+	require.True(t, elem.RemoveAttr(``, `xmlns`))
+	require.True(t, elem.RemoveAttr(``, `five`))
+	require.True(t, elem.RemoveAttr(``, `three`))
+	require.False(t, elem.RemoveAttr(``, `three`))
+
+	expected, err := Parser{}.Parse([]byte(`<one xmlns="two"/>`))
+	require.NoError(t, err)
+	expectedElem := expected[0].(Elem)
+	require.True(t, expectedElem.RemoveAttr(``, `xmlns`))
+
+	require.NotNil(t, elem.Attrs)
+	require.Equal(t, expectedElem, elem)
+	require.True(t, Equal(Nodes{elem}, Nodes{Elem{Name: Name{Space: `two`, Local: `one`}, Attrs: []Attr{}}}, EqualOptions{}))
+}
+
+func TestTextContent(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<?one?><two>three <four>five<!--six--><seven/><![CDATA[<eight>]]></four> nine</two>ten`))
+	require.NoError(t, err)
+
+	require.Equal(t, `three five<eight> nine`, doc[1].(Elem).TextContent())
+	require.Equal(t, `three five<eight> nineten`, doc.TextContent())
+	require.Equal(t, ``, Elem{}.TextContent())
+	require.Equal(t, `one`, Nodes{&Elem{Nodes: Nodes{Nodes{Text(`one`)}}}}.TextContent())
+}
+
+func TestNodesElements(t *testing.T) {
+	require.Equal(t, []Elem{expectedSimple[2].(Elem)}, expectedSimple.Elements())
+	require.Nil(t, Nodes{Text(`one`), Comment(`two`)}.Elements())
+
+	two := &Elem{Name: Name{Local: `two`}}
+	nodes := Nodes{Text("\n"), (*Elem)(nil), two, Elem{Name: Name{Local: `three`}}, Nodes{Elem{Name: Name{Local: `four`}}}}
+	require.Equal(t, []Elem{*two, {Name: Name{Local: `three`}}}, nodes.Elements())
+
+	first, ok := nodes.First()
+	require.True(t, ok)
+	require.Equal(t, Text("\n"), first)
+
+	_, ok = Nodes(nil).First()
+	require.False(t, ok)
+
+	elem, ok := nodes.FirstElement()
+	require.True(t, ok)
+	require.True(t, elem == two)
+
+	elem, ok = expectedSimple.FirstElement()
+	require.True(t, ok)
+	require.Equal(t, `one`, elem.Name.Local)
+
+	_, ok = Nodes{Text(`one`)}.FirstElement()
+	require.False(t, ok)
+}
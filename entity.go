@@ -0,0 +1,257 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Delimiters of the placeholders for entity references, used by
+`Parser.PreserveEntityRefs`. These are noncharacters, which are allowed in XML
+and by `encoding/xml`. Occurrences of the delimiters in text, either literal or
+via character references, are escaped as placeholders whose content is not a
+name, which makes the placeholders unambiguous:
+
+	&name;       ->  entityStart + "name" + entityEnd
+	entityStart  ->  entityStart + entityEnd
+	entityEnd    ->  entityStart + "-" + entityEnd
+*/
+const (
+	entityStart = '\U0010FFFE'
+	entityEnd   = '\U0010FFFF'
+)
+
+// Content of the placeholder for a literal `entityEnd`.
+const entityEndEscape = `-`
+
+/*
+Rewrites references to general entities in text, other than the predefined
+ones, into placeholders which `encoding/xml` decodes as regular text. Markup,
+comments, CDATA sections and processing instructions are copied as-is. The
+placeholders are longer than the references, which shifts byte offsets, but
+not line numbers. Also see `restoreEntityRefs`.
+*/
+func prepareEntityRefs(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+
+	for len(src) > 0 {
+		ind := bytes.IndexAny(src, `<&`)
+		if ind < 0 {
+			return appendEntityText(out, src)
+		}
+		out = appendEntityText(out, src[:ind])
+		src = src[ind:]
+
+		if src[0] == '<' {
+			skip := rawSectionLen(src)
+			if skip == 0 {
+				skip = tagLen(src)
+			}
+			out = append(out, src[:skip]...)
+			src = src[skip:]
+			continue
+		}
+
+		if bytes.HasPrefix(src, []byte(`&#`)) {
+			char, size := parseCharRef(src)
+			if size > 0 && (char == entityStart || char == entityEnd) {
+				out = appendEntityText(out, []byte(string(char)))
+				src = src[size:]
+				continue
+			}
+		}
+
+		name, size := parseEntityRef(src)
+		if size == 0 {
+			out = append(out, src[0])
+			src = src[1:]
+			continue
+		}
+		out = append(out, string(entityStart)...)
+		out = append(out, name...)
+		out = append(out, string(entityEnd)...)
+		src = src[size:]
+	}
+	return out
+}
+
+// Appends text, escaping the placeholder delimiters.
+func appendEntityText(out, src []byte) []byte {
+	for len(src) > 0 {
+		ind := bytes.IndexAny(src, string([]rune{entityStart, entityEnd}))
+		if ind < 0 {
+			return append(out, src...)
+		}
+		out = append(out, src[:ind]...)
+
+		char, size := utf8.DecodeRune(src[ind:])
+		out = append(out, string(entityStart)...)
+		if char == entityEnd {
+			out = append(out, entityEndEscape...)
+		}
+		out = append(out, string(entityEnd)...)
+		src = src[ind+size:]
+	}
+	return out
+}
+
+/*
+Parses a reference to a general entity such as `&copy;`, returning its name
+and length, or 0 length if invalid or predefined.
+*/
+func parseEntityRef(src []byte) (string, int) {
+	end := bytes.IndexByte(src, ';')
+	if end < 0 {
+		return "", 0
+	}
+
+	name := string(src[1:end])
+	switch name {
+	case `lt`, `gt`, `amp`, `apos`, `quot`:
+		return "", 0
+	}
+	if !isName(name) {
+		return "", 0
+	}
+	return name, end + 1
+}
+
+/*
+Length of the tag or declaration at the start of the input, up to and
+including the closing ">", skipping quoted values and, for declarations such
+as `<!DOCTYPE>`, the internal subset in brackets.
+*/
+func tagLen(src []byte) int {
+	var quote byte
+	depth := 0
+
+	for ind, char := range src {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '[':
+			depth++
+		case char == ']':
+			depth--
+		case char == '>' && depth <= 0:
+			return ind + 1
+		}
+	}
+	return len(src)
+}
+
+/*
+Replaces the placeholders inserted by `prepareEntityRefs` with `EntityRef`
+nodes, splitting the text nodes containing them. Unchanged sequences are
+returned as-is, and changed ones are copied, which preserves any memory sharing
+between subtrees.
+*/
+func restoreEntityRefs(nodes Nodes) Nodes {
+	var out Nodes
+
+	for ind, node := range nodes {
+		var next Nodes
+		changed := false
+
+		switch node := node.(type) {
+		case Text:
+			if strings.ContainsRune(string(node), entityStart) {
+				next, changed = splitEntityRefs(string(node)), true
+			}
+
+		case Elem:
+			children := restoreEntityRefs(node.Nodes)
+			if !sameNodes(children, node.Nodes) {
+				node.Nodes = children
+				next, changed = Nodes{node}, true
+			}
+		}
+
+		if changed && out == nil {
+			out = append(make(Nodes, 0, len(nodes)), nodes[:ind]...)
+		}
+		if changed {
+			out = append(out, next...)
+		} else if out != nil {
+			out = append(out, node)
+		}
+	}
+
+	if out == nil {
+		return nodes
+	}
+	return out
+}
+
+func splitEntityRefs(val string) Nodes {
+	var out Nodes
+	var text strings.Builder
+
+	for {
+		start := strings.IndexRune(val, entityStart)
+		if start < 0 {
+			break
+		}
+		end := strings.IndexRune(val[start:], entityEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+
+		text.WriteString(val[:start])
+		switch name := val[start+len(string(entityStart)) : end]; name {
+		case "":
+			text.WriteRune(entityStart)
+		case entityEndEscape:
+			text.WriteRune(entityEnd)
+		default:
+			if text.Len() > 0 {
+				out = append(out, Text(text.String()))
+				text.Reset()
+			}
+			out = append(out, EntityRef(name))
+		}
+		val = val[end+len(string(entityEnd)):]
+	}
+
+	text.WriteString(val)
+	if text.Len() > 0 {
+		out = append(out, Text(text.String()))
+	}
+	return out
+}
+
+/*
+Replaces the placeholders in text recorded by `Parser.ParseWithTokens` with
+the original references, such as `&copy;`, and with the escaped delimiters.
+*/
+func restoreEntityTokens(tokens []xml.Token) {
+	for ind, tok := range tokens {
+		text, ok := tok.(xml.CharData)
+		if !ok || !bytes.ContainsRune(text, entityStart) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		for _, node := range splitEntityRefs(string(text)) {
+			switch node := node.(type) {
+			case Text:
+				buf.WriteString(string(node))
+			case EntityRef:
+				buf.WriteString(`&` + string(node) + `;`)
+			}
+		}
+		tokens[ind] = xml.CharData(buf.Bytes())
+	}
+}
+
+// True if both slices refer to the same memory, or are both empty.
+func sameNodes(a, b Nodes) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,93 @@
+package xt
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePreserveEntityRefs(t *testing.T) {
+	src := []byte(`<!DOCTYPE one [<!ENTITY copy "&#169;">]>` +
+		`<one two="&amp;"><!-- &three; -->&copy; 2021 &amp; &#169;<four>&nbsp;</four><![CDATA[&five;]]>&copy;</one>`)
+
+	_, err := Parser{}.Parse(src)
+	require.Error(t, err)
+
+	doc, err := Parser{PreserveEntityRefs: true}.Parse(src)
+	require.NoError(t, err)
+
+	require.Equal(t, Nodes{
+		Decl(`DOCTYPE one [<!ENTITY copy "&#169;">]`),
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{{Name: Name{Local: `two`}, Value: `&`}},
+			Nodes: Nodes{
+				Comment(` &three; `),
+				EntityRef(`copy`),
+				Text(" 2021 & \u00a9"),
+				Elem{Name: Name{Local: `four`}, Attrs: []Attr{}, Nodes: Nodes{EntityRef(`nbsp`)}},
+				CData(`&five;`),
+				EntityRef(`copy`),
+			},
+		},
+	}, doc)
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<!DOCTYPE one [<!ENTITY copy "&#169;">]><one two="&amp;"><!-- &three; -->&copy; 2021 &amp; `+"\u00a9"+`<four>&nbsp;</four><![CDATA[&five;]]>&copy;</one>`, string(out))
+
+	_, err = xml.Marshal(doc)
+	require.EqualError(t, err, `can't encode XML entity reference &copy; via encoding/xml; use MarshalOptions`)
+
+	_, err = MarshalOptions{}.Marshal(EntityRef(`one two`))
+	require.Error(t, err)
+
+	shared, err := Parser{PreserveEntityRefs: true, ShareSubtrees: true}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, doc, shared)
+}
+
+func TestParsePreserveEntityRefsPlaceholders(t *testing.T) {
+	src := []byte("<one>\U0010FFFEtwo\U0010FFFF &#x10FFFE;&three;&#1114111;\U0010FFFF</one>")
+
+	doc, tokens, err := Parser{PreserveEntityRefs: true}.ParseWithTokens(src)
+	require.NoError(t, err)
+
+	require.Equal(t, Nodes{
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{},
+			Nodes: Nodes{
+				Text("\U0010FFFEtwo\U0010FFFF \U0010FFFE"),
+				EntityRef(`three`),
+				Text("\U0010FFFF\U0010FFFF"),
+			},
+		},
+	}, doc)
+
+	require.Equal(t, xml.CharData("\U0010FFFEtwo\U0010FFFF \U0010FFFE&three;\U0010FFFF\U0010FFFF"), tokens[1])
+
+	shared, err := Parser{PreserveEntityRefs: true, ShareSubtrees: true}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, doc, shared)
+}
+
+func TestEntityRefJSON(t *testing.T) {
+	doc := Nodes{Text(`one`), EntityRef(`two`)}
+
+	out, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"text","content":"one"},{"type":"entity","name":"two"}]`, string(out))
+
+	var decoded Nodes
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, doc, decoded)
+
+	content, err := doc.MarshalBinary()
+	require.NoError(t, err)
+	decoded = nil
+	require.NoError(t, decoded.UnmarshalBinary(content))
+	require.Equal(t, doc, decoded)
+}
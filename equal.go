@@ -0,0 +1,242 @@
+package xt
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+Options for `Equal`. The zero value compares nodes strictly, except that nil
+and empty slices are considered equal, and namespace prefixes are ignored.
+*/
+type EqualOptions struct {
+	/**
+	Also compare namespace prefixes: `Elem.Prefix` and namespace declarations
+	such as `xmlns:one="..."`. By default, names are compared only by their
+	namespace URIs, and namespace declarations are skipped, since they only
+	bind prefixes; names are already resolved to URIs when decoding. Ignoring
+	prefixes is the semantically correct comparison for XML.
+	*/
+	RespectPrefixes bool
+
+	/**
+	When set, both sequences are normalized via `Nodes.Normalize` with this
+	policy before comparison, ignoring differences in whitespace which the
+	policy considers formatting.
+	*/
+	Whitespace WhitespacePolicy
+
+	/**
+	Compare attribute values by their inferred types rather than as strings.
+	Values which are both decimal numbers, as recognized by `Nodes.Unmarshal`,
+	are compared numerically, so "1.0" equals "1" and "1e3" equals "1000".
+	Values which are both booleans, "true" or "false" in any case, are
+	compared as booleans. Surrounding whitespace is ignored for such values.
+	Other values are compared as strings.
+
+	This is a heuristic: attributes which merely look numeric, such as
+	version numbers "1.10" and "1.1", are considered equal.
+	*/
+	TypedAttrs bool
+
+	/**
+	Skip whitespace-only `Text` nodes on both sides, at any depth, like
+	`Nodes.StripInsignificantWhitespace`. Other text is compared exactly. For
+	trimming whitespace around text, use `Whitespace`.
+	*/
+	IgnoreWhitespace bool
+
+	/**
+	Compare attributes regardless of order: elements are equal when their
+	attributes are permutations of each other.
+	*/
+	IgnoreAttrOrder bool
+
+	/**
+	Skip comments on both sides, at any depth. Text separated by a comment is
+	not merged, so "one<!---->two" still differs from "onetwo".
+	*/
+	IgnoreComments bool
+}
+
+/*
+True if the node sequences are equivalent under the given options. `Elem` and
+`*Elem` are compared by value. A nil `*Elem` equals only another nil `*Elem`.
+*/
+func Equal(a, b Nodes, opts EqualOptions) bool {
+	if opts.Whitespace != nil {
+		norm := NormalizeOptions{Whitespace: opts.Whitespace}
+		a = a.Normalize(norm)
+		b = b.Normalize(norm)
+		opts.Whitespace = nil
+	}
+	return opts.nodes(a, b)
+}
+
+func (self EqualOptions) nodes(a, b Nodes) bool {
+	a = self.significant(a)
+	b = self.significant(b)
+
+	if len(a) != len(b) {
+		return false
+	}
+	for ind := range a {
+		if !self.node(a[ind], b[ind]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Omits the nodes skipped by `IgnoreWhitespace` and `IgnoreComments`. Returns the
+same slice when nothing is skipped.
+*/
+func (self EqualOptions) significant(nodes Nodes) Nodes {
+	if !self.IgnoreWhitespace && !self.IgnoreComments {
+		return nodes
+	}
+
+	var out Nodes
+	for ind, node := range nodes {
+		skip := self.IgnoreWhitespace && isWhitespaceText(node)
+		if !skip && self.IgnoreComments {
+			_, skip = node.(Comment)
+		}
+
+		if skip && out == nil {
+			out = append(make(Nodes, 0, len(nodes)), nodes[:ind]...)
+		}
+		if !skip && out != nil {
+			out = append(out, node)
+		}
+	}
+
+	if out == nil {
+		return nodes
+	}
+	return out
+}
+
+func (self EqualOptions) node(a, b Node) bool {
+	ptrA, okA := a.(*Elem)
+	ptrB, okB := b.(*Elem)
+	if (okA && ptrA == nil) || (okB && ptrB == nil) {
+		return okA && okB && ptrA == nil && ptrB == nil
+	}
+	if okA {
+		a = *ptrA
+	}
+	if okB {
+		b = *ptrB
+	}
+
+	switch a := a.(type) {
+	case Elem:
+		b, ok := b.(Elem)
+		return ok && self.elem(a, b)
+	case Nodes:
+		b, ok := b.(Nodes)
+		return ok && self.nodes(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func (self EqualOptions) elem(a, b Elem) bool {
+	return a.Name == b.Name &&
+		(!self.RespectPrefixes || a.Prefix == b.Prefix) &&
+		self.attrs(a.Attrs, b.Attrs) &&
+		self.nodes(a.Nodes, b.Nodes)
+}
+
+func (self EqualOptions) attrs(a, b []Attr) bool {
+	if !self.RespectPrefixes {
+		a = withoutNamespaceDecls(a)
+		b = withoutNamespaceDecls(b)
+	}
+
+	if len(a) != len(b) {
+		return false
+	}
+	if self.IgnoreAttrOrder {
+		return self.attrsUnordered(a, b)
+	}
+	for ind := range a {
+		if !self.attr(a[ind], b[ind]) {
+			return false
+		}
+	}
+	return true
+}
+
+// True if every attribute in `a` matches a distinct attribute in `b`.
+func (self EqualOptions) attrsUnordered(a, b []Attr) bool {
+	used := make([]bool, len(b))
+outer:
+	for _, attrA := range a {
+		for ind, attrB := range b {
+			if !used[ind] && self.attr(attrA, attrB) {
+				used[ind] = true
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (self EqualOptions) attr(a, b Attr) bool {
+	if a == b {
+		return true
+	}
+	return self.TypedAttrs && a.Name == b.Name && equalTyped(a.Value, b.Value)
+}
+
+func equalTyped(a, b string) bool {
+	a = strings.Trim(a, whitespace)
+	b = strings.Trim(b, whitespace)
+
+	if isDecimal(a) && isDecimal(b) {
+		numA, errA := strconv.ParseFloat(a, 64)
+		numB, errB := strconv.ParseFloat(b, 64)
+		return errA == nil && errB == nil && numA == numB
+	}
+
+	boolA, okA := parseBool(a)
+	boolB, okB := parseBool(b)
+	return okA && okB && boolA == boolB
+}
+
+func parseBool(val string) (bool, bool) {
+	switch {
+	case strings.EqualFold(val, `true`):
+		return true, true
+	case strings.EqualFold(val, `false`):
+		return false, true
+	}
+	return false, false
+}
+
+func withoutNamespaceDecls(attrs []Attr) []Attr {
+	if !hasNamespaceDecl(attrs) {
+		return attrs
+	}
+
+	out := make([]Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if !IsNamespaceDecl(attr) {
+			out = append(out, attr)
+		}
+	}
+	return out
+}
+
+func hasNamespaceDecl(attrs []Attr) bool {
+	for _, attr := range attrs {
+		if IsNamespaceDecl(attr) {
+			return true
+		}
+	}
+	return false
+}
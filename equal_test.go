@@ -0,0 +1,108 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	require.True(t, Equal(expectedSimple, expectedSimple, EqualOptions{}))
+	require.True(t, Equal(nil, Nodes{}, EqualOptions{}))
+	require.False(t, Equal(expectedSimple, expectedSimple[1:], EqualOptions{}))
+
+	require.True(t, Equal(
+		Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{}}},
+		Nodes{&Elem{Name: Name{Local: `one`}}},
+		EqualOptions{},
+	))
+
+	require.False(t, Equal(
+		Nodes{Elem{Name: Name{Local: `one`}, Nodes: Nodes{Text(`two`)}}},
+		Nodes{Elem{Name: Name{Local: `one`}, Nodes: Nodes{Comment(`two`)}}},
+		EqualOptions{},
+	))
+
+	withNil := Nodes{E(`one`).C((*Elem)(nil))}
+	require.True(t, Equal(withNil, withNil, EqualOptions{}))
+	require.False(t, Equal(withNil, Nodes{E(`one`).C(E(`two`))}, EqualOptions{}))
+	require.False(t, Equal(Nodes{E(`one`).C(&Elem{})}, withNil, EqualOptions{}))
+	require.False(t, Equal(withNil, Nodes{E(`one`).C(Elem{})}, EqualOptions{}))
+}
+
+func TestEqualRespectPrefixes(t *testing.T) {
+	require.True(t, Equal(expectedNsAliased, expectedNsInlined, EqualOptions{}))
+	require.False(t, Equal(expectedNsAliased, expectedNsInlined, EqualOptions{RespectPrefixes: true}))
+
+	require.False(t, Equal(
+		Nodes{Elem{Name: Name{Space: `one`, Local: `two`}}},
+		Nodes{Elem{Name: Name{Space: `three`, Local: `two`}}},
+		EqualOptions{},
+	))
+
+	prefixed := Nodes{Elem{Name: Name{Space: `one`, Local: `two`}, Prefix: `three`}}
+	unprefixed := Nodes{Elem{Name: Name{Space: `one`, Local: `two`}}}
+	require.True(t, Equal(prefixed, unprefixed, EqualOptions{}))
+	require.False(t, Equal(prefixed, unprefixed, EqualOptions{RespectPrefixes: true}))
+	require.True(t, Equal(prefixed, prefixed, EqualOptions{RespectPrefixes: true}))
+}
+
+func TestEqualTypedAttrs(t *testing.T) {
+	test := func(a, b string, expected bool) {
+		t.Helper()
+
+		docA := Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{{Name: Name{Local: `count`}, Value: a}}}}
+		docB := Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{{Name: Name{Local: `count`}, Value: b}}}}
+
+		require.Equal(t, a == b, Equal(docA, docB, EqualOptions{}), `%q vs %q`, a, b)
+		require.Equal(t, expected, Equal(docA, docB, EqualOptions{TypedAttrs: true}), `%q vs %q`, a, b)
+	}
+
+	test(`1.0`, `1`, true)
+	test(`1e3`, `1000`, true)
+	test(` 2 `, `2.00`, true)
+	test(`-0`, `0`, true)
+	test(`1.5`, `1.50`, true)
+	test(`1`, `2`, false)
+	test(`TRUE`, `true`, true)
+	test(`False`, `false`, true)
+	test(`true`, `1`, false)
+	test(`007`, `7`, false)
+	test(`one`, `One`, false)
+	test(`one`, `one`, true)
+
+	require.False(t, Equal(
+		Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{{Name: Name{Local: `two`}, Value: `1`}}}},
+		Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{{Name: Name{Local: `three`}, Value: `1.0`}}}},
+		EqualOptions{TypedAttrs: true},
+	))
+}
+
+func TestEqualIgnoreWhitespaceAttrOrderComments(t *testing.T) {
+	parse := func(src string) Nodes {
+		t.Helper()
+		doc, err := Parser{}.Parse([]byte(src))
+		require.NoError(t, err)
+		return doc
+	}
+
+	test := func(a, b string, opts EqualOptions, expected bool) {
+		t.Helper()
+		require.Equal(t, expected, Equal(parse(a), parse(b), opts))
+		require.Equal(t, expected, Equal(parse(b), parse(a), opts))
+	}
+
+	pretty := "<one>\n  <two a=\"1\" b=\"2\">three</two>\n  <!-- four -->\n</one>"
+	compact := `<one><two b="2" a="1">three</two></one>`
+
+	test(pretty, compact, EqualOptions{}, false)
+	test(pretty, compact, EqualOptions{IgnoreWhitespace: true, IgnoreComments: true}, false)
+	test(pretty, compact, EqualOptions{IgnoreWhitespace: true, IgnoreAttrOrder: true}, false)
+	test(pretty, compact, EqualOptions{IgnoreComments: true, IgnoreAttrOrder: true}, false)
+	test(pretty, compact, EqualOptions{IgnoreWhitespace: true, IgnoreAttrOrder: true, IgnoreComments: true}, true)
+
+	test(`<one> two </one>`, `<one>two</one>`, EqualOptions{IgnoreWhitespace: true}, false)
+	test(`<one a="1" a="1" b="2"/>`, `<one a="1" b="2" b="2"/>`, EqualOptions{IgnoreAttrOrder: true}, false)
+	test(`<one a="1.0" b="2"/>`, `<one b="2" a="1"/>`, EqualOptions{IgnoreAttrOrder: true, TypedAttrs: true}, true)
+	test(`<one>two<!---->three</one>`, `<one>twothree</one>`, EqualOptions{IgnoreComments: true}, false)
+}
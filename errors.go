@@ -0,0 +1,60 @@
+package xt
+
+import "strings"
+
+/*
+Returned by `Parser`, `DecodeSafe`, and `Elem.UnmarshalXML` (and therefore
+`Nodes.Decode` and `DecodeToken`) when the underlying `xml.Decoder` fails
+inside an element, such as on malformed markup, or when the input ends inside
+an element, which is reported as `io.ErrUnexpectedEOF`. Describes where in the
+document structure the failure occurred, which is often more useful than the
+byte offset reported by `encoding/xml`:
+
+	decoding <one><six>: XML syntax error on line 3: unexpected EOF
+
+Use `errors.As` to detect it, and `errors.Unwrap` to obtain the original error.
+Errors which occur outside of any element, and limit errors, which already
+describe the problem, are returned as-is.
+*/
+type ElementStackError struct {
+	/**
+	Names of the enclosing elements, outermost first.
+	*/
+	Stack []Name
+
+	/**
+	Original error returned by the decoder.
+	*/
+	Err error
+}
+
+func (self ElementStackError) Error() string {
+	var buf strings.Builder
+	buf.WriteString(`decoding `)
+	for _, name := range self.Stack {
+		buf.WriteString(`<`)
+		buf.WriteString(name.clark())
+		buf.WriteString(`>`)
+	}
+	buf.WriteString(`: `)
+	if self.Err != nil {
+		buf.WriteString(self.Err.Error())
+	}
+	return buf.String()
+}
+
+func (self ElementStackError) Unwrap() error { return self.Err }
+
+/*
+Used by `Elem.UnmarshalXML`, which only knows its own name, to build the stack
+as the error propagates from the innermost element outwards. Other errors are
+returned as-is.
+*/
+func withParentElem(name Name, err error) error {
+	stackErr, ok := err.(ElementStackError)
+	if !ok {
+		return err
+	}
+	stackErr.Stack = append([]Name{name}, stackErr.Stack...)
+	return stackErr
+}
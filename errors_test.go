@@ -0,0 +1,99 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElementStackError(t *testing.T) {
+	src := []byte(`<one><two/><six xmlns="seven"><eight></nine></six></one>`)
+
+	_, err := Parser{}.Parse(src)
+	require.EqualError(t, err, `decoding <one><{seven}six><{seven}eight>: XML syntax error on line 1: element <eight> closed by </nine>`)
+
+	var stackErr ElementStackError
+	require.True(t, errors.As(err, &stackErr))
+	require.Equal(t, []Name{{Local: `one`}, {Space: `seven`, Local: `six`}, {Space: `seven`, Local: `eight`}}, stackErr.Stack)
+
+	var syntaxErr *xml.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+
+	_, err = DecodeSafe(xml.NewDecoder(bytes.NewReader([]byte(`<one><two>`))), Limits{})
+	require.EqualError(t, err, `decoding <one><two>: XML syntax error on line 1: unexpected EOF`)
+
+	_, err = Parser{}.Parse([]byte(`<one></one></two>`))
+	require.Error(t, err)
+	require.False(t, errors.As(err, &stackErr))
+}
+
+func TestElementStackErrorDecode(t *testing.T) {
+	src := []byte(`<one><two/><six xmlns="seven"><eight>`)
+
+	var nodes Nodes
+	err := nodes.Decode(xml.NewDecoder(bytes.NewReader(src)))
+	require.EqualError(t, err, `decoding <one><{seven}six><{seven}eight>: XML syntax error on line 1: unexpected EOF`)
+
+	var stackErr ElementStackError
+	require.True(t, errors.As(err, &stackErr))
+	require.Equal(t, []Name{{Local: `one`}, {Space: `seven`, Local: `six`}, {Space: `seven`, Local: `eight`}}, stackErr.Stack)
+
+	var syntaxErr *xml.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+
+	var elem Elem
+	err = xml.NewDecoder(iotest.TimeoutReader(bytes.NewReader([]byte(`<one><two>`)))).Decode(&elem)
+	require.EqualError(t, err, `decoding <one><two>: timeout`)
+	require.True(t, errors.Is(err, iotest.ErrTimeout))
+
+	err = xml.NewDecoder(iotest.ErrReader(io.EOF)).Decode(&elem)
+	require.True(t, errors.Is(err, io.EOF))
+}
+
+func TestElementStackErrorUnexpectedEOF(t *testing.T) {
+	tokens := tokenSlice{
+		xml.StartElement{Name: xml.Name{Local: `one`}},
+		xml.CharData(`two`),
+		xml.StartElement{Name: xml.Name{Local: `three`}},
+	}
+
+	var nodes Nodes
+	err := nodes.Decode(xml.NewTokenDecoder(&tokens))
+	require.EqualError(t, err, `decoding <one><three>: XML syntax error on line 1: unexpected EOF`)
+
+	// The decoder doesn't know about the start token, and can't detect the
+	// missing end token.
+	tokens = tokenSlice{xml.CharData(`two`)}
+
+	var elem Elem
+	err = elem.UnmarshalXML(xml.NewTokenDecoder(&tokens), xml.StartElement{Name: xml.Name{Local: `one`}})
+	require.EqualError(t, err, `decoding <one>: unexpected EOF`)
+	require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	tokens = tokenSlice{
+		xml.StartElement{Name: xml.Name{Local: `one`}},
+		xml.EndElement{Name: xml.Name{Local: `one`}},
+		xml.Comment(`two`),
+	}
+
+	nodes = nil
+	require.NoError(t, nodes.Decode(xml.NewTokenDecoder(&tokens)))
+	require.Equal(t, Nodes{Elem{Name: Name{Local: `one`}}, Comment(`two`)}, nodes)
+}
+
+// Implements `xml.TokenReader` by returning the tokens in order, then `io.EOF`.
+type tokenSlice []xml.Token
+
+func (self *tokenSlice) Token() (xml.Token, error) {
+	if len(*self) == 0 {
+		return nil, io.EOF
+	}
+	tok := (*self)[0]
+	*self = (*self)[1:]
+	return tok, nil
+}
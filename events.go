@@ -0,0 +1,121 @@
+package xt
+
+/*
+Kind of an `Event`. The zero value is invalid.
+*/
+type EventKind byte
+
+const (
+	EventStart EventKind = iota + 1
+	EventEnd
+	EventText
+	EventComment
+	EventPi
+	EventDecl
+	EventCData
+	EventEntityRef
+	EventDoctype
+)
+
+func (self EventKind) String() string {
+	switch self {
+	case EventStart:
+		return "start"
+	case EventEnd:
+		return "end"
+	case EventText:
+		return "text"
+	case EventComment:
+		return "comment"
+	case EventPi:
+		return "pi"
+	case EventDecl:
+		return "decl"
+	case EventCData:
+		return "cdata"
+	case EventEntityRef:
+		return "entity"
+	case EventDoctype:
+		return "doctype"
+	}
+	return "invalid"
+}
+
+/*
+SAX-style event, produced by `Nodes.Events`. Only the fields relevant to the
+event's kind are set:
+
+	EventStart      Name, Attrs
+	EventEnd        Name
+	EventText       Content
+	EventComment    Content
+	EventPi         Target, Content
+	EventDecl       Content
+	EventCData      Content
+	EventEntityRef  Name.Local
+	EventDoctype    Content, as for the equivalent `Decl`
+
+Unlike `xml.Token`, events use the types of this package, which makes them
+convenient for assertions and pipeline stages. The `Attrs` slice is shared
+with the source element.
+*/
+type Event struct {
+	Kind    EventKind
+	Name    Name
+	Attrs   []Attr
+	Target  string
+	Content string
+}
+
+/*
+Flattens the nodes into a linear sequence of events in document order. Each
+element produces a start event, the events of its child nodes, and an end
+event. Nested `Nodes` are flattened. Nodes which can't be represented as
+events, such as nil `*Elem`, produce no events.
+*/
+func (self Nodes) Events() []Event {
+	var out []Event
+	return self.appendEvents(out)
+}
+
+func (self Nodes) appendEvents(out []Event) []Event {
+	for _, node := range self {
+		out = appendNodeEvents(out, node)
+	}
+	return out
+}
+
+func appendNodeEvents(out []Event, node Node) []Event {
+	switch node := node.(type) {
+	case Pi:
+		return append(out, Event{Kind: EventPi, Target: node.Target, Content: node.Content})
+	case Decl:
+		return append(out, Event{Kind: EventDecl, Content: string(node)})
+	case Comment:
+		return append(out, Event{Kind: EventComment, Content: string(node)})
+	case Text:
+		return append(out, Event{Kind: EventText, Content: string(node)})
+	case CData:
+		return append(out, Event{Kind: EventCData, Content: string(node)})
+	case EntityRef:
+		return append(out, Event{Kind: EventEntityRef, Name: Name{Local: string(node)}})
+	case Doctype:
+		return append(out, Event{Kind: EventDoctype, Content: string(node.Decl())})
+	case Elem:
+		return node.appendEvents(out)
+	case *Elem:
+		if node == nil {
+			return out
+		}
+		return node.appendEvents(out)
+	case Nodes:
+		return node.appendEvents(out)
+	}
+	return out
+}
+
+func (self Elem) appendEvents(out []Event) []Event {
+	out = append(out, Event{Kind: EventStart, Name: self.Name, Attrs: self.Attrs})
+	out = self.Nodes.appendEvents(out)
+	return append(out, Event{Kind: EventEnd, Name: self.Name})
+}
@@ -0,0 +1,53 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	doc := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0"`},
+		Elem{
+			Name:  Name{Local: "one"},
+			Attrs: []Attr{{Name: Name{Local: "two"}, Value: "three"}},
+			Nodes: Nodes{
+				Text("four"),
+				Elem{
+					Name:  Name{Space: "ns", Local: "five"},
+					Nodes: Nodes{Comment(" six ")},
+				},
+				Decl("seven"),
+			},
+		},
+	}
+
+	expected := []Event{
+		{Kind: EventPi, Target: `xml`, Content: `version="1.0"`},
+		{Kind: EventStart, Name: Name{Local: "one"}, Attrs: []Attr{{Name: Name{Local: "two"}, Value: "three"}}},
+		{Kind: EventText, Content: "four"},
+		{Kind: EventStart, Name: Name{Space: "ns", Local: "five"}},
+		{Kind: EventComment, Content: " six "},
+		{Kind: EventEnd, Name: Name{Space: "ns", Local: "five"}},
+		{Kind: EventDecl, Content: "seven"},
+		{Kind: EventEnd, Name: Name{Local: "one"}},
+	}
+
+	require.Equal(t, expected, doc.Events())
+}
+
+func TestEventsNested(t *testing.T) {
+	doc := Nodes{
+		Nodes{Text(`one`)},
+		E(`two`).C(Nodes{Comment(`three`)}, (*Elem)(nil)),
+		(*Elem)(nil),
+	}
+
+	require.Equal(t, []Event{
+		{Kind: EventText, Content: `one`},
+		{Kind: EventStart, Name: Name{Local: `two`}},
+		{Kind: EventComment, Content: `three`},
+		{Kind: EventEnd, Name: Name{Local: `two`}},
+	}, doc.Events())
+}
@@ -0,0 +1,222 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+Parses an XML fragment: an arbitrary sequence of nodes, such as the content of
+an element, which may have several top-level elements or none.
+
+The optional `ancestors` provide the namespace context, from the outermost to
+the immediate parent of the fragment. Prefixes declared by the ancestors may be
+used in the fragment, and unprefixed element names in the fragment belong to
+the ancestors' default namespace. The default namespace of an element is its
+own namespace unless it declares a different one, matching how such elements
+are encoded. CDATA sections are preserved as `CData`, like in `Parser`.
+*/
+func ParseFragment(src string, ancestors ...*Elem) (Nodes, error) {
+	var head strings.Builder
+	head.WriteString(`<fragment`)
+	for _, decl := range fragmentScope(ancestors) {
+		head.WriteString(` `)
+		head.WriteString(NamespaceXMLNS)
+		if decl.prefix != "" {
+			head.WriteString(`:`)
+			head.WriteString(decl.prefix)
+		}
+		head.WriteString(`="`)
+		_ = xml.EscapeText(&head, []byte(decl.uri))
+		head.WriteString(`"`)
+	}
+	head.WriteString(`>`)
+	head.WriteString(src)
+	head.WriteString(`</fragment>`)
+
+	// The decoder needs the source to detect CDATA sections.
+	input := []byte(head.String())
+	dec := decoder{Decoder: xml.NewDecoder(bytes.NewReader(input)), src: input}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper, err := dec.elem(tok.(xml.StartElement))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf(`invalid XML fragment: unexpected content after end of fragment`)
+	}
+	return wrapper.Nodes, nil
+}
+
+/*
+Encodes the child nodes of the element as a string, like the DOM property
+`innerHTML`. Prefixes and the default namespace declared by the element remain
+in scope and are not redeclared, so the result can be parsed back via
+`Elem.SetInnerXML` on the same element. Redundant namespace declarations in
+the content are omitted; see `MarshalOptions.DedupeNamespaces`.
+*/
+func (self Elem) InnerXML() (string, error) {
+	var buf bytes.Buffer
+	err := MarshalOptions{DedupeNamespaces: true}.write(&buf, self.Nodes, fragmentScope([]*Elem{&self}))
+	return buf.String(), err
+}
+
+/*
+Parses the string as an XML fragment via `ParseFragment`, using the element as
+the namespace context, and replaces the element's child nodes with the result.
+On error, the element is not modified.
+*/
+func (self *Elem) SetInnerXML(src string) error {
+	nodes, err := ParseFragment(src, self)
+	if err != nil {
+		return err
+	}
+	self.Nodes = nodes
+	return nil
+}
+
+/*
+Returns the namespace bindings in scope for the content of the innermost
+element, ordered from outermost to innermost. The empty prefix stands for the
+default namespace.
+*/
+func fragmentScope(ancestors []*Elem) []nsDecl {
+	var out []nsDecl
+
+	bind := func(decl nsDecl) {
+		for ind, prev := range out {
+			if prev.prefix == decl.prefix {
+				out = append(out[:ind], out[ind+1:]...)
+				break
+			}
+		}
+		out = append(out, decl)
+	}
+
+	for _, elem := range ancestors {
+		if elem.Name.Space != "" && !hasDefaultDecl(elem.Attrs) {
+			bind(nsDecl{"", elem.Name.Space})
+		}
+		for _, attr := range elem.Attrs {
+			prefix, uri, ok := attr.DeclaredPrefix()
+			if ok {
+				bind(nsDecl{prefix, uri})
+			}
+		}
+	}
+	return out
+}
+
+func hasDefaultDecl(attrs []Attr) bool {
+	for _, attr := range attrs {
+		prefix, _, ok := attr.DeclaredPrefix()
+		if ok && prefix == "" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Encodes the element, including its own tags, like the DOM property
+`outerHTML`. Equivalent to `MarshalOptions{}.Marshal(self)`.
+*/
+func (self Elem) OuterXML() (string, error) {
+	out, err := MarshalOptions{}.Marshal(self)
+	return string(out), err
+}
+
+/*
+Encodes the element and its descendants, for writing a single edited subtree
+back into a larger document, such as at the byte range of the original
+element. Same as `Elem.OuterXML`, but returns bytes. Preserves CDATA sections,
+`Elem.RawAttrs` and entity references, like `MarshalOptions`.
+*/
+func (self Elem) EncodeXML() ([]byte, error) {
+	return MarshalOptions{}.Marshal(self)
+}
+
+/*
+Parses the string as an XML fragment and replaces the target element with the
+resulting nodes, which may be any number of nodes, including none. The
+fragment is parsed via `ParseFragment`, in the namespace context of the
+target's ancestors.
+
+The target is located by pointer identity, among elements stored as `*Elem`;
+elements stored as `Elem` can't be targeted. Returns an error if the target is
+not found or the fragment is malformed; the tree is not modified in that case.
+
+The nodes along the path to the target are copied rather than modified
+in-place: the receiver gets new `Nodes` slices, and ancestors stored as
+`*Elem` are replaced with pointers to modified copies. Trees sharing memory
+with the original are not affected.
+*/
+func (self *Nodes) ReplaceOuterXML(target *Elem, src string) error {
+	repl := outerReplacer{target: target, src: src}
+	out, err := repl.nodes(*self, nil)
+	if err != nil {
+		return err
+	}
+	if !repl.done {
+		return fmt.Errorf(`can't replace element <%s>: not found in tree`, target.Name.clark())
+	}
+	*self = out
+	return nil
+}
+
+type outerReplacer struct {
+	target *Elem
+	src    string
+	done   bool
+}
+
+/*
+Returns a copy of the nodes with the target replaced, or the original nodes
+if the target is not among them or their descendants.
+*/
+func (self *outerReplacer) nodes(nodes Nodes, ancestors []*Elem) (Nodes, error) {
+	for ind, node := range nodes {
+		elem, ok := nodeElem(node)
+		if !ok {
+			continue
+		}
+
+		if ptr, ok := node.(*Elem); ok && ptr == self.target {
+			repl, err := ParseFragment(self.src, ancestors...)
+			if err != nil {
+				return nil, err
+			}
+			self.done = true
+
+			out := make(Nodes, 0, len(nodes)-1+len(repl))
+			out = append(out, nodes[:ind]...)
+			out = append(out, repl...)
+			out = append(out, nodes[ind+1:]...)
+			return out, nil
+		}
+
+		children, err := self.nodes(elem.Nodes, append(ancestors, elem))
+		if err != nil {
+			return nil, err
+		}
+		if !self.done {
+			continue
+		}
+
+		copied := *elem
+		copied.Nodes = children
+		return replaceNode(nodes, ind, copied), nil
+	}
+	return nodes, nil
+}
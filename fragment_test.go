@@ -0,0 +1,150 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInnerXML(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<p xmlns="ns_html" xmlns:x="ns_x">one <b x:two="three">four <i>five</i></b><!-- six --></p>`))
+	require.NoError(t, err)
+	elem := doc[0].(Elem)
+
+	inner, err := elem.InnerXML()
+	require.NoError(t, err)
+	require.Equal(t, `one <b x:two="three">four <i>five</i></b><!-- six -->`, inner)
+
+	other := elem
+	require.NoError(t, other.SetInnerXML(inner))
+	require.Equal(t, elem, other)
+
+	require.NoError(t, other.SetInnerXML(`<x:seven/>eight`))
+	require.Equal(t, Nodes{
+		Elem{Name: Name{Space: `ns_x`, Local: `seven`}, Attrs: []Attr{}},
+		Text(`eight`),
+	}, other.Nodes)
+
+	require.Error(t, other.SetInnerXML(`<nine>`))
+	require.Error(t, other.SetInnerXML(`</fragment><ten/>`))
+	require.Equal(t, Text(`eight`), other.Nodes[1], `must not modify on error`)
+}
+
+func TestParseFragment(t *testing.T) {
+	nodes, err := ParseFragment(`one<two/><three>four</three>`)
+	require.NoError(t, err)
+	require.Equal(t, Nodes{
+		Text(`one`),
+		Elem{Name: Name{Local: `two`}, Attrs: []Attr{}},
+		Elem{Name: Name{Local: `three`}, Attrs: []Attr{}, Nodes: Nodes{Text(`four`)}},
+	}, nodes)
+
+	nodes, err = ParseFragment(``)
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+
+	elem := Elem{Name: Name{Local: `one`}}
+	require.NoError(t, elem.SetInnerXML(`two<![CDATA[<three>]]><four><![CDATA[five]]></four>`))
+	require.Equal(t, Nodes{
+		Text(`two`),
+		CData(`<three>`),
+		Elem{Name: Name{Local: `four`}, Attrs: []Attr{}, Nodes: Nodes{CData(`five`)}},
+	}, elem.Nodes)
+
+	content, err := elem.InnerXML()
+	require.NoError(t, err)
+	require.Equal(t, `two<![CDATA[<three>]]><four><![CDATA[five]]></four>`, content)
+
+	outer := &Elem{Name: Name{Space: `ns_outer`, Local: `one`}, Attrs: []Attr{{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_p`}}}
+	inner := &Elem{Name: Name{Local: `two`}, Attrs: []Attr{{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_q`}}}
+
+	nodes, err = ParseFragment(`<three/><p:four/>`, outer, inner)
+	require.NoError(t, err)
+	require.Equal(t, Name{Space: `ns_outer`, Local: `three`}, nodes[0].(Elem).Name)
+	require.Equal(t, Name{Space: `ns_q`, Local: `four`}, nodes[1].(Elem).Name)
+}
+
+func TestOuterXML(t *testing.T) {
+	elem := Elem{
+		Name:  Name{Local: `one`},
+		Attrs: []Attr{{Name: Name{Local: `two`}, Value: `three`}},
+		Nodes: Nodes{Elem{Name: Name{Local: `four`}}},
+	}
+
+	out, err := elem.OuterXML()
+	require.NoError(t, err)
+	require.Equal(t, `<one two="three"><four></four></one>`, out)
+}
+
+func TestEncodeXML(t *testing.T) {
+	elem := Elem{
+		Name:  Name{Space: `ns_one`, Local: `one`},
+		Attrs: []Attr{{Name: Name{Local: `two`}, Value: `<three>`}},
+		Nodes: Nodes{Elem{Name: Name{Local: `four`}}, CData(`five`), EntityRef(`six`)},
+	}
+
+	out, err := elem.EncodeXML()
+	require.NoError(t, err)
+	require.Equal(t, `<one xmlns="ns_one" two="&lt;three&gt;"><four></four><![CDATA[five]]>&six;</one>`, string(out))
+
+	outer, err := elem.OuterXML()
+	require.NoError(t, err)
+	require.Equal(t, outer, string(out))
+
+	raw := Elem{
+		Name:     Name{Local: `one`},
+		Attrs:    []Attr{{Name: Name{Local: `two`}, Value: "\t"}},
+		RawAttrs: []string{`&#9;`},
+	}
+	out, err = raw.EncodeXML()
+	require.NoError(t, err)
+	require.Equal(t, `<one two="&#9;"></one>`, string(out))
+
+	_, err = Elem{}.EncodeXML()
+	require.EqualError(t, err, `can't XML-encode xt.Elem with empty name`)
+
+	_, err = Elem{Name: Name{Local: `one`}, Nodes: Nodes{Elem{}}}.EncodeXML()
+	require.EqualError(t, err, `can't XML-encode xt.Elem with empty name`)
+}
+
+func TestReplaceOuterXML(t *testing.T) {
+	target := &Elem{Name: Name{Local: `three`}}
+	same := Elem{Name: Name{Local: `three`}}
+
+	doc := Nodes{Elem{
+		Name:  Name{Space: `ns_one`, Local: `one`},
+		Attrs: []Attr{{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_p`}},
+		Nodes: Nodes{same, Elem{Name: Name{Local: `two`}, Nodes: Nodes{target}}},
+	}}
+
+	require.NoError(t, doc.ReplaceOuterXML(target, `<four/>five<p:six/>`))
+	require.Equal(t, Nodes{
+		same,
+		Elem{Name: Name{Local: `two`}, Nodes: Nodes{
+			Elem{Name: Name{Space: `ns_one`, Local: `four`}, Attrs: []Attr{}},
+			Text(`five`),
+			Elem{Name: Name{Space: `ns_p`, Local: `six`}, Attrs: []Attr{}},
+		}},
+	}, doc[0].(Elem).Nodes)
+
+	// Elements are matched by identity rather than by value.
+	before := doc.Clone()
+	require.EqualError(t, doc.ReplaceOuterXML(&Elem{Name: Name{Local: `three`}}, ``), `can't replace element <three>: not found in tree`)
+	require.EqualError(t, doc.ReplaceOuterXML(target, `<seven/>`), `can't replace element <three>: not found in tree`)
+	require.Equal(t, before, doc)
+
+	siblings := Nodes{E(`x`), E(`x`)}
+	require.Error(t, siblings.ReplaceOuterXML(E(`x`), `<y/>`))
+	require.Equal(t, Nodes{E(`x`), E(`x`)}, siblings)
+
+	// The original tree, and its pointers, are not modified.
+	inner := E(`three`)
+	outer := E(`two`).C(inner)
+	orig := Nodes{outer}
+	doc = orig
+	require.Error(t, doc.ReplaceOuterXML(inner, `<eight>`))
+	require.NoError(t, doc.ReplaceOuterXML(inner, `<eight/>`))
+	require.Equal(t, Nodes{E(`two`).C(Elem{Name: Name{Local: `eight`}, Attrs: []Attr{}})}, doc)
+	require.Equal(t, Nodes{E(`two`).C(E(`three`))}, orig)
+	require.Equal(t, Nodes{inner}, outer.Nodes)
+}
@@ -0,0 +1,52 @@
+package xt
+
+import (
+	"encoding/json"
+	"encoding/xml"
+)
+
+/*
+Read-only view of a tree, created by `Nodes.Freeze`. Safe for concurrent use by
+multiple goroutines, which makes it suitable for caching parsed documents
+shared between requests.
+
+The view holds a private deep copy of the nodes. Nothing inside it is shared
+with the original tree: in particular, attribute slices are copied rather than
+aliased. This matters because decoding reinterprets `[]xml.Attr` as `[]Attr`
+without copying, so a decoded tree may share memory with slices still
+referenced elsewhere. Every method that returns nodes returns a new deep copy,
+which callers may freely modify.
+*/
+type ImmutableNodes struct{ nodes Nodes }
+
+// Returns a read-only view of a deep copy of the nodes. See `ImmutableNodes`.
+func (self Nodes) Freeze() ImmutableNodes {
+	return ImmutableNodes{self.Clone()}
+}
+
+// Returns a mutable deep copy of the frozen nodes.
+func (self ImmutableNodes) Nodes() Nodes { return self.nodes.Clone() }
+
+// Returns the number of top-level nodes.
+func (self ImmutableNodes) Len() int { return len(self.nodes) }
+
+/*
+Same as `Nodes.Select`, but returns deep copies of the matching nodes rather
+than the nodes themselves.
+*/
+func (self ImmutableNodes) Select(path string, namespaces map[string]string) (Nodes, error) {
+	out, err := self.nodes.Select(path, namespaces)
+	return out.Clone(), err
+}
+
+var _ = xml.Marshaler(ImmutableNodes{})
+
+func (self ImmutableNodes) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	return self.nodes.MarshalXML(enc, start)
+}
+
+var _ = json.Marshaler(ImmutableNodes{})
+
+func (self ImmutableNodes) MarshalJSON() ([]byte, error) {
+	return self.nodes.MarshalJSON()
+}
@@ -0,0 +1,92 @@
+package xt
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeze(t *testing.T) {
+	doc := expectedSimple.Clone()
+	frozen := doc.Freeze()
+
+	doc[2].(Elem).Attrs[0].Value = `mutated`
+	require.Equal(t, expectedSimple, frozen.Nodes())
+	require.Equal(t, len(expectedSimple), frozen.Len())
+
+	out := frozen.Nodes()
+	out[2].(Elem).Attrs[0].Value = `mutated`
+	require.Equal(t, expectedSimple, frozen.Nodes())
+
+	selected, err := frozen.Select(`//six`, nil)
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	selected[0].(Elem).Nodes[1].(Elem).Attrs[0].Value = `mutated`
+	require.Equal(t, expectedSimple, frozen.Nodes())
+}
+
+func TestFreezeDecodedAttrs(t *testing.T) {
+	attrs := []xml.Attr{{Name: xml.Name{Local: `two`}, Value: `three`}}
+	doc := Nodes{Elem{Name: Name{Local: `one`}, Attrs: attrsFrom(attrs)}}
+	frozen := doc.Freeze()
+
+	attrs[0].Value = `mutated`
+	require.Equal(t, `three`, frozen.Nodes()[0].(Elem).Attrs[0].Value)
+}
+
+func TestFreezeConcurrentReads(t *testing.T) {
+	frozen := expectedSimple.Freeze()
+	expectedJSON, err := json.Marshal(expectedSimple)
+	require.NoError(t, err)
+
+	read := func() error {
+		for ind := 0; ind < 100; ind++ {
+			out, err := json.Marshal(frozen)
+			if err != nil {
+				return err
+			}
+			if string(out) != string(expectedJSON) {
+				return fmt.Errorf(`unexpected JSON: %s`, out)
+			}
+
+			_, err = xml.Marshal(frozen)
+			if err != nil {
+				return err
+			}
+
+			nodes, err := frozen.Select(`//nine`, nil)
+			if err != nil {
+				return err
+			}
+			if len(nodes) != 1 {
+				return fmt.Errorf(`expected 1 node, found %d`, len(nodes))
+			}
+
+			frozen.Nodes()[2].(Elem).Attrs[0].Value = `mutated`
+		}
+		return nil
+	}
+
+	// `require` must not be used outside of the test goroutine.
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for ind := 0; ind < cap(errs); ind++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- read()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, expectedSimple, frozen.Nodes())
+}
@@ -0,0 +1,198 @@
+package xt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Decodes the nodes into generic Go data, for when the exact XML structure
+doesn't matter. Supported targets are `*map[string]interface{}`,
+`*[]interface{}` and `*interface{}`:
+
+	*map[string]interface{}  top-level elements, keyed by name
+	*interface{}             same as above
+	*[]interface{}           values of top-level elements, in document order
+
+Elements are converted as follows:
+
+	<a></a>                      nil
+	<a> 12 </a>                  int64(12)
+	<a>one</a>                   "one"
+	<a b="2"><c>true</c></a>     map[string]interface{}{"b": int64(2), "c": true}
+	<a b="c">text</a>            map[string]interface{}{"b": "c", "#text": "text"}
+
+Text is trimmed of surrounding whitespace, and whitespace-only text is ignored,
+which removes formatting. Values are inferred: "true" and "false" become
+`bool`, decimal integers become `int64`, other decimal numbers become `float64`,
+everything else remains `string`. Numbers with leading zeros, such as "007",
+remain strings.
+
+This conversion is lossy. Namespaces are ignored, as are comments, processing
+instructions and declarations. The text of elements with attributes or child
+elements is concatenated and stored under the "#text" key. Attributes and
+child elements share the same keys; when a key occurs more than once, its
+values are collected into `[]interface{}` in document order, attributes
+first. As a result, the type of a value may depend on the number of
+occurrences in a given document.
+*/
+func (self Nodes) Unmarshal(out interface{}) error {
+	switch out := out.(type) {
+	case *map[string]interface{}:
+		*out = genericNodes(self)
+	case *interface{}:
+		*out = genericNodes(self)
+	case *[]interface{}:
+		*out = genericList(self)
+	default:
+		return fmt.Errorf(`can't unmarshal XML nodes into %T`, out)
+	}
+	return nil
+}
+
+func genericNodes(nodes Nodes) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, node := range nodes {
+		elem, ok := nodeElem(node)
+		if ok {
+			addGenericValue(out, elem.Name.Local, genericElem(*elem))
+		}
+	}
+	return out
+}
+
+func genericList(nodes Nodes) []interface{} {
+	out := []interface{}{}
+	for _, node := range nodes {
+		elem, ok := nodeElem(node)
+		if ok {
+			out = append(out, genericElem(*elem))
+		}
+	}
+	return out
+}
+
+func genericElem(elem Elem) interface{} {
+	text := strings.Trim(elem.Nodes.ownText(), whitespace)
+
+	var out map[string]interface{}
+	for _, attr := range elem.Attrs {
+		if IsNamespaceDecl(attr) {
+			continue
+		}
+		if out == nil {
+			out = map[string]interface{}{}
+		}
+		addGenericValue(out, attr.Name.Local, inferValue(attr.Value))
+	}
+
+	for _, node := range elem.Nodes {
+		child, ok := nodeElem(node)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = map[string]interface{}{}
+		}
+		addGenericValue(out, child.Name.Local, genericElem(*child))
+	}
+
+	if out == nil {
+		if text == "" {
+			return nil
+		}
+		return inferValue(text)
+	}
+
+	if text != "" {
+		addGenericValue(out, `#text`, inferValue(text))
+	}
+	return out
+}
+
+func addGenericValue(out map[string]interface{}, key string, val interface{}) {
+	prev, ok := out[key]
+	if !ok {
+		out[key] = val
+		return
+	}
+
+	// Element and attribute values are never lists, so a list can only come
+	// from a previously repeated key.
+	list, ok := prev.([]interface{})
+	if !ok {
+		list = []interface{}{prev}
+	}
+	out[key] = append(list, val)
+}
+
+func inferValue(val string) interface{} {
+	switch val {
+	case `true`:
+		return true
+	case `false`:
+		return false
+	}
+
+	if !isDecimal(val) {
+		return val
+	}
+
+	num, err := strconv.ParseInt(val, 10, 64)
+	if err == nil {
+		return num
+	}
+
+	float, err := strconv.ParseFloat(val, 64)
+	if err == nil {
+		return float
+	}
+	return val
+}
+
+/*
+True if the string is a decimal number in the format accepted by JSON, such as
+"-12", "0.5" or "1e3". Unlike `strconv.ParseFloat`, this rejects leading zeros,
+"Inf", "NaN", hexadecimal and underscores.
+*/
+func isDecimal(val string) bool {
+	val = strings.TrimPrefix(val, `-`)
+
+	digits := func() int {
+		ind := 0
+		for ind < len(val) && val[ind] >= '0' && val[ind] <= '9' {
+			ind++
+		}
+		return ind
+	}
+
+	count := digits()
+	if count == 0 || (count > 1 && val[0] == '0') {
+		return false
+	}
+	val = val[count:]
+
+	if strings.HasPrefix(val, `.`) {
+		val = val[1:]
+		count = digits()
+		if count == 0 {
+			return false
+		}
+		val = val[count:]
+	}
+
+	if strings.HasPrefix(val, `e`) || strings.HasPrefix(val, `E`) {
+		val = val[1:]
+		if strings.HasPrefix(val, `+`) || strings.HasPrefix(val, `-`) {
+			val = val[1:]
+		}
+		count = digits()
+		if count == 0 {
+			return false
+		}
+		val = val[count:]
+	}
+
+	return val == ""
+}
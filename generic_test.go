@@ -0,0 +1,77 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalGeneric(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `generic.xml`))
+	require.NoError(t, err)
+
+	expected := map[string]interface{}{
+		`inventory`: map[string]interface{}{
+			`updated`: `2021-03-05`,
+			`item`: []interface{}{
+				map[string]interface{}{
+					`id`:     int64(1),
+					`active`: true,
+					`name`:   `Widget`,
+					`price`:  12.5,
+					`code`:   `007`,
+					`tag`:    []interface{}{`one`, `two`},
+				},
+				map[string]interface{}{
+					`id`:     int64(2),
+					`active`: false,
+					`name`:   map[string]interface{}{`lang`: `en`, `#text`: `Gadget`},
+					`price`:  int64(3),
+					`note`:   nil,
+				},
+			},
+		},
+	}
+
+	var out map[string]interface{}
+	require.NoError(t, doc.Unmarshal(&out))
+	require.Equal(t, expected, out)
+
+	var val interface{}
+	require.NoError(t, doc.Unmarshal(&val))
+	require.Equal(t, expected, val)
+
+	var list []interface{}
+	require.NoError(t, doc.Unmarshal(&list))
+	require.Equal(t, []interface{}{expected[`inventory`]}, list)
+
+	var str string
+	require.EqualError(t, doc.Unmarshal(&str), `can't unmarshal XML nodes into *string`)
+}
+
+func TestInferValue(t *testing.T) {
+	test := func(src string, expected interface{}) {
+		t.Helper()
+		require.Equal(t, expected, inferValue(src))
+	}
+
+	test(`true`, true)
+	test(`false`, false)
+	test(`True`, `True`)
+	test(`0`, int64(0))
+	test(`-12`, int64(-12))
+	test(`12.5`, 12.5)
+	test(`-1e3`, -1000.0)
+	test(`1E+2`, 100.0)
+	test(`99999999999999999999`, 1e20)
+	test(`007`, `007`)
+	test(`1.`, `1.`)
+	test(`.5`, `.5`)
+	test(`1e`, `1e`)
+	test(`1e+-2`, `1e+-2`)
+	test(`0x10`, `0x10`)
+	test(`1_000`, `1_000`)
+	test(`Inf`, `Inf`)
+	test(`NaN`, `NaN`)
+	test(``, ``)
+}
@@ -2,5 +2,8 @@ module github.com/purelabio/xt
 
 go 1.16
 
-// These dependencies are test-only.
-require github.com/stretchr/testify v1.7.0
+require (
+	// These dependencies are test-only.
+	github.com/stretchr/testify v1.7.0
+	github.com/xeipuuv/gojsonschema v1.2.0
+)
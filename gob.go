@@ -0,0 +1,23 @@
+package xt
+
+import "encoding/gob"
+
+/*
+Registers the node types with `encoding/gob`, which requires concrete types
+stored in interfaces, such as `Node`, to be registered. This allows to encode
+`Nodes` and `Elem` via gob without any setup.
+
+`*Elem` doesn't need separate registration: gob transmits pointers as the
+values they point to, and decodes them as `Elem`.
+*/
+func init() {
+	gob.Register(Pi{})
+	gob.Register(Decl(""))
+	gob.Register(Comment(""))
+	gob.Register(Text(""))
+	gob.Register(CData(""))
+	gob.Register(EntityRef(""))
+	gob.Register(Doctype{})
+	gob.Register(Elem{})
+	gob.Register(Nodes(nil))
+}
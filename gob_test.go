@@ -0,0 +1,28 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGob(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(expectedSimple))
+
+	var doc Nodes
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&doc))
+	require.Equal(t, expectedSimple, doc)
+}
+
+func TestGobInterface(t *testing.T) {
+	var buf bytes.Buffer
+	var node Node = &Elem{Name: Name{Local: `one`}, Nodes: Nodes{Text(`two`), Comment(`three`)}}
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&node))
+
+	var out Node
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&out))
+	require.Equal(t, Elem{Name: Name{Local: `one`}, Nodes: Nodes{Text(`two`), Comment(`three`)}}, out)
+}
@@ -0,0 +1,58 @@
+package xt
+
+/*
+Lookup tables for repeated queries over the same tree, built by
+`Nodes.BuildIndex`. Turns linear searches into map lookups.
+
+The index refers to the elements as they were when it was built, and is
+invalidated by any mutation of the tree. Elements stored as `*Elem` are indexed
+as-is, while elements stored as `Elem` are indexed as pointers to copies.
+*/
+type Index struct {
+	byName map[Name][]*Elem
+	byID   map[string]*Elem
+}
+
+/*
+Builds an index of all elements in the tree, by name and by ID. IDs are the
+values of `id` and `xml:id` attributes; when an ID is duplicated, the first
+element in document order wins.
+*/
+func (self Nodes) BuildIndex() *Index {
+	out := &Index{
+		byName: map[Name][]*Elem{},
+		byID:   map[string]*Elem{},
+	}
+
+	walkPath(self, nil, func(_ Path, node Node) {
+		elem, ok := nodeElem(node)
+		if !ok {
+			return
+		}
+
+		out.byName[elem.Name] = append(out.byName[elem.Name], elem)
+
+		for _, attr := range elem.Attrs {
+			if !isIDAttr(attr.Name) {
+				continue
+			}
+			_, ok := out.byID[attr.Value]
+			if !ok {
+				out.byID[attr.Value] = elem
+			}
+		}
+	})
+
+	return out
+}
+
+// Returns all elements with exactly the given name, in document order.
+func (self *Index) ByName(name Name) []*Elem {
+	return self.byName[name]
+}
+
+// Returns the first element with the given ID, in document order.
+func (self *Index) ByID(id string) (*Elem, bool) {
+	elem, ok := self.byID[id]
+	return elem, ok
+}
@@ -0,0 +1,75 @@
+package xt
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	doc := benchDoc(3)
+	index := doc.BuildIndex()
+
+	for _, name := range []Name{{Local: `item`}, {Local: `value`}, {Local: `root`}, {Local: `missing`}} {
+		require.Equal(t, findAllLinear(doc, name), index.ByName(name), name.Local)
+	}
+
+	elem, ok := index.ByID(`item_1`)
+	require.True(t, ok)
+	require.Equal(t, findAllLinear(doc, Name{Local: `item`})[1], elem)
+
+	_, ok = index.ByID(`missing`)
+	require.False(t, ok)
+}
+
+func BenchmarkFindLinear(b *testing.B) {
+	doc := benchDoc(1000)
+	b.ResetTimer()
+
+	for ind := 0; ind < b.N; ind++ {
+		findByIDLinear(doc, `item_`+strconv.Itoa(ind%1000))
+	}
+}
+
+func BenchmarkFindIndexed(b *testing.B) {
+	doc := benchDoc(1000)
+	index := doc.BuildIndex()
+	b.ResetTimer()
+
+	for ind := 0; ind < b.N; ind++ {
+		index.ByID(`item_` + strconv.Itoa(ind%1000))
+	}
+}
+
+func benchDoc(count int) Nodes {
+	var items Nodes
+	for ind := 0; ind < count; ind++ {
+		items = append(items, Text("\n  "), Elem{
+			Name:  Name{Local: `item`},
+			Attrs: []Attr{{Name: Name{Local: `id`}, Value: `item_` + strconv.Itoa(ind)}},
+			Nodes: Nodes{Elem{Name: Name{Local: `value`}, Nodes: Nodes{Text(strconv.Itoa(ind))}}},
+		})
+	}
+	return Nodes{Elem{Name: Name{Local: `root`}, Nodes: items}}
+}
+
+func findAllLinear(nodes Nodes, name Name) (out []*Elem) {
+	walkPath(nodes, nil, func(_ Path, node Node) {
+		elem, ok := nodeElem(node)
+		if ok && elem.Name == name {
+			out = append(out, elem)
+		}
+	})
+	return
+}
+
+func findByIDLinear(nodes Nodes, id string) (out *Elem) {
+	walkPath(nodes, nil, func(_ Path, node Node) {
+		elem, ok := nodeElem(node)
+		if ok && out == nil && hasExactAttr(elem.Attrs, "", `id`, id) {
+			out = elem
+		}
+	})
+	return
+}
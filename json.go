@@ -0,0 +1,300 @@
+package xt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+Decodes a single node from its JSON object form, such as
+`{"type": "text", "content": "one"}`, into the appropriate concrete type:
+`Pi`, `Decl`, `Comment`, `Text`, `CData`, `EntityRef`, `Doctype` or `Elem`. This is
+useful for nodes embedded in larger JSON documents. For arrays of nodes, use `(*Nodes).UnmarshalJSON`.
+*/
+func UnmarshalNodeJSON(input []byte) (Node, error) {
+	var out nodeDecoder
+	err := out.UnmarshalJSON(input)
+	return out.Node, err
+}
+
+/*
+Encodes the nodes as a JSON array with one top-level node per line, each node
+fully on its line, including its descendants:
+
+	[
+	  {"type":"pi","target":"xml","content":"version=\"1.0\""},
+	  {"type":"elem","name":{"local":"one"},"nodes":[...]}
+	]
+
+This is more compact than `json.MarshalIndent`, and friendlier to line-based
+diffs than `json.Marshal`. Unlike JSON Lines, the output is a single valid
+JSON array, decodable via `(*Nodes).UnmarshalJSON`.
+*/
+func MarshalJSONLines(nodes Nodes) ([]byte, error) {
+	if len(nodes) == 0 {
+		return json.Marshal(nodes)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+
+	for ind, node := range nodes {
+		line, err := json.Marshal(node)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString(`  `)
+		buf.Write(line)
+		if ind < len(nodes)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+/*
+Encodes the nodes as JSON like `Nodes.MarshalJSON`, but with attributes in a
+friendlier map-like shape which still preserves their order: an array of
+single-key objects, one per attribute, in the original order:
+
+	<one two="three" xml:lang="en" />
+	->
+	{"type": "elem", "name": {"local": "one"}, "attrs": [
+		{"two": "three"},
+		{"{http://www.w3.org/XML/1998/namespace}lang": "en"}
+	]}
+
+Attribute names without a namespace are used as-is. Other names are in "Clark
+notation": "{space}local". This includes prefixed namespace declarations, such
+as "{xmlns}p". Decode via `UnmarshalJSONOrderedAttrMap`.
+*/
+func MarshalJSONOrderedAttrMap(nodes Nodes) ([]byte, error) {
+	return json.Marshal(orderedAttrMapJSON(nodes))
+}
+
+/*
+Decodes nodes encoded via `MarshalJSONOrderedAttrMap`. Each attribute object
+must have exactly one key.
+*/
+func UnmarshalJSONOrderedAttrMap(input []byte) (Nodes, error) {
+	var out orderedAttrMapJSON
+	err := json.Unmarshal(input, &out)
+	return Nodes(out), err
+}
+
+/*
+Encodes the nodes as JSON into the writer, producing the same output as
+`json.Marshal`, but incrementally, without first building the entire output in
+memory. Elements are written piece by piece: the element's own fields, then
+each child node in turn, so memory usage is bounded by the largest leaf node
+rather than the document. Output is buffered; the writer receives the data in
+chunks. The JSON counterpart of encoding via `xml.Encoder`.
+*/
+func (self Nodes) EncodeJSON(out io.Writer) error {
+	buf := bufio.NewWriter(out)
+	err := encodeJSONNodes(buf, self)
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+func encodeJSONNodes(out *bufio.Writer, nodes Nodes) error {
+	if nodes == nil {
+		_, err := out.WriteString(`null`)
+		return err
+	}
+
+	out.WriteByte('[')
+	for ind, node := range nodes {
+		if ind > 0 {
+			out.WriteByte(',')
+		}
+		err := encodeJSONNode(out, node)
+		if err != nil {
+			return err
+		}
+	}
+	return out.WriteByte(']')
+}
+
+func encodeJSONNode(out *bufio.Writer, node Node) error {
+	switch node := node.(type) {
+	case Elem:
+		return encodeJSONElem(out, node)
+	case *Elem:
+		return encodeJSONElem(out, *node)
+	case Nodes:
+		return encodeJSONNodes(out, node)
+	}
+
+	chunk, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(chunk)
+	return err
+}
+
+/*
+Writes the element like `Elem.MarshalJSON`, encoding all fields other than
+"nodes" at once, then streaming the child nodes.
+*/
+func encodeJSONElem(out *bufio.Writer, elem Elem) error {
+	head, err := json.Marshal(struct {
+		typeHead
+		Name     Name     `json:"name,omitempty"`
+		Prefix   string   `json:"prefix,omitempty"`
+		Attrs    []Attr   `json:"attrs,omitempty"`
+		RawAttrs []string `json:"rawAttrs,omitempty"`
+	}{typeHead{TypeElem}, elem.Name, elem.Prefix, elem.Attrs, elem.RawAttrs})
+	if err != nil {
+		return err
+	}
+
+	if len(elem.Nodes) == 0 {
+		_, err = out.Write(head)
+		return err
+	}
+
+	// The head always ends with "}", since it always has at least the "type"
+	// and "name" fields.
+	out.Write(head[:len(head)-1])
+	out.WriteString(`,"nodes":`)
+	err = encodeJSONNodes(out, elem.Nodes)
+	if err != nil {
+		return err
+	}
+	return out.WriteByte('}')
+}
+
+type orderedAttrMapJSON Nodes
+
+func (self orderedAttrMapJSON) MarshalJSON() ([]byte, error) {
+	if self == nil {
+		return []byte(`null`), nil
+	}
+
+	out := make([]interface{}, 0, len(self))
+	for _, node := range self {
+		elem, ok := nodeElem(node)
+		if !ok {
+			out = append(out, node)
+			continue
+		}
+
+		val := orderedAttrMapElem{
+			typeHead: typeHead{TypeElem},
+			Name:     elem.Name,
+			Prefix:   elem.Prefix,
+			RawAttrs: elem.RawAttrs,
+			Nodes:    orderedAttrMapJSON(elem.Nodes),
+		}
+		if elem.Attrs != nil {
+			val.Attrs = make([]orderedAttr, len(elem.Attrs))
+			for ind, attr := range elem.Attrs {
+				val.Attrs[ind] = orderedAttr(attr)
+			}
+		}
+		out = append(out, val)
+	}
+	return json.Marshal(out)
+}
+
+func (self *orderedAttrMapJSON) UnmarshalJSON(input []byte) error {
+	var items []json.RawMessage
+	err := json.Unmarshal(input, &items)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		*self = nil
+		return nil
+	}
+
+	out := make(orderedAttrMapJSON, 0, len(items))
+	for _, item := range items {
+		var head typeHead
+		err := json.Unmarshal(item, &head)
+		if err != nil {
+			return err
+		}
+
+		if head.Type != TypeElem {
+			node, err := UnmarshalNodeJSON(item)
+			if err != nil {
+				return err
+			}
+			out = append(out, node)
+			continue
+		}
+
+		var val orderedAttrMapElem
+		err = json.Unmarshal(item, &val)
+		if err != nil {
+			return err
+		}
+
+		elem := Elem{Name: val.Name, Prefix: val.Prefix, RawAttrs: val.RawAttrs, Nodes: Nodes(val.Nodes)}
+		if val.Attrs != nil {
+			elem.Attrs = make([]Attr, len(val.Attrs))
+			for ind, attr := range val.Attrs {
+				elem.Attrs[ind] = Attr(attr)
+			}
+		}
+		out = append(out, elem)
+	}
+
+	*self = out
+	return nil
+}
+
+type orderedAttrMapElem struct {
+	typeHead
+	Name     Name               `json:"name,omitempty"`
+	Prefix   string             `json:"prefix,omitempty"`
+	Attrs    []orderedAttr      `json:"attrs,omitempty"`
+	RawAttrs []string           `json:"rawAttrs,omitempty"`
+	Nodes    orderedAttrMapJSON `json:"nodes,omitempty"`
+}
+
+type orderedAttr Attr
+
+func (self orderedAttr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{self.Name.clark(): self.Value})
+}
+
+func (self *orderedAttr) UnmarshalJSON(input []byte) error {
+	var val map[string]string
+	err := json.Unmarshal(input, &val)
+	if err != nil {
+		return err
+	}
+	if len(val) != 1 {
+		return fmt.Errorf(`expected attribute object with exactly one key, got %d keys in %q`, len(val), input)
+	}
+
+	for key, value := range val {
+		*self = orderedAttr{Name: parseClark(key), Value: value}
+	}
+	return nil
+}
+
+// Inverse of `Name.clark`.
+func parseClark(val string) Name {
+	if strings.HasPrefix(val, `{`) {
+		ind := strings.LastIndexByte(val, '}')
+		if ind > 0 {
+			return Name{Space: val[1:ind], Local: val[ind+1:]}
+		}
+	}
+	return Name{Local: val}
+}
@@ -0,0 +1,169 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalNodeJSON(t *testing.T) {
+	test := func(src string, expected Node) {
+		t.Helper()
+
+		out, err := UnmarshalNodeJSON([]byte(src))
+		require.NoError(t, err)
+		require.Equal(t, expected, out)
+	}
+
+	test(`{"type": "pi", "target": "one", "content": "two"}`, Pi{Target: `one`, Content: `two`})
+	test(`{"type": "decl", "content": "one two"}`, Decl(`one two`))
+	test(`{"type": "comment", "content": "one"}`, Comment(`one`))
+	test(`{"type": "text", "content": "one"}`, Text(`one`))
+	test(`{"type": "cdata", "content": "<one>"}`, CData(`<one>`))
+	test(
+		`{"type": "elem", "name": {"local": "one"}, "attrs": [{"name": {"local": "two"}, "value": "three"}], "nodes": [{"type": "text", "content": "four"}]}`,
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{{Name: Name{Local: `two`}, Value: `three`}},
+			Nodes: Nodes{Text(`four`)},
+		},
+	)
+}
+
+func TestUnmarshalNodeJSONInvalid(t *testing.T) {
+	_, err := UnmarshalNodeJSON([]byte(`{"content": "one"}`))
+	require.EqualError(t, err, `required field "type" is missing in "{\"content\": \"one\"}"`)
+
+	_, err = UnmarshalNodeJSON([]byte(`{"type": "one"}`))
+	require.EqualError(t, err, `unrecognized node type "one"`)
+
+	_, err = UnmarshalNodeJSON([]byte(`[]`))
+	require.Error(t, err)
+}
+
+func TestMarshalJSONLines(t *testing.T) {
+	out, err := MarshalJSONLines(expectedSimple)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(out), "\n")
+	require.Len(t, lines, len(expectedSimple)+2)
+	require.Equal(t, `[`, lines[0])
+	require.Equal(t, `  {"type":"pi","target":"xml","content":"version=\"1.0\" encoding=\"utf-8\""},`, lines[1])
+	require.Equal(t, `  {"type":"text","content":"\n"},`, lines[2])
+	require.True(t, strings.HasPrefix(lines[3], `  {"type":"elem","name":{"local":"one"}`))
+	require.Equal(t, `]`, lines[len(lines)-1])
+
+	var doc Nodes
+	require.NoError(t, json.Unmarshal(out, &doc))
+	require.Equal(t, expectedSimple, doc)
+
+	out, err = MarshalJSONLines(nil)
+	require.NoError(t, err)
+	require.Equal(t, `null`, string(out))
+
+	out, err = MarshalJSONLines(Nodes{})
+	require.NoError(t, err)
+	require.Equal(t, `[]`, string(out))
+}
+
+func TestEncodeJSON(t *testing.T) {
+	test := func(nodes Nodes) {
+		t.Helper()
+
+		expected, err := json.Marshal(nodes)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, nodes.EncodeJSON(&buf))
+		require.Equal(t, string(expected), buf.String())
+	}
+
+	test(nil)
+	test(Nodes{})
+	test(expectedSimple)
+	test(expectedNsAliased)
+	test(benchDoc(10))
+	test(Nodes{
+		&Elem{Name: Name{Space: `one`, Local: `two`}, Prefix: `p`, Attrs: []Attr{}, Nodes: Nodes{Text(`<three>`)}},
+		Nodes{Comment(`four`), Nodes(nil)},
+		Elem{Nodes: Nodes{}},
+	})
+
+	doc, err := Parser{RawAttrs: true}.Parse([]byte(`<one two="&#9;three"><four five='six'/></one>`))
+	require.NoError(t, err)
+	require.NotNil(t, doc[0].(Elem).RawAttrs)
+	test(doc)
+
+	require.Error(t, Nodes{Text(`one`)}.EncodeJSON(errWriter{}))
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
+func TestMarshalJSONOrderedAttrMap(t *testing.T) {
+	doc := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0"`},
+		Elem{
+			Name: Name{Local: `one`},
+			Attrs: []Attr{
+				{Name: Name{Local: `zeta`}, Value: `1`},
+				{Name: Name{Local: `alpha`}, Value: `2`},
+				{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_p`},
+				{Name: Name{Space: `ns_p`, Local: `mid`}, Value: `3`},
+			},
+			Nodes: Nodes{&Elem{Name: Name{Local: `two`}, Attrs: []Attr{{Name: Name{Local: `b`}}, {Name: Name{Local: `a`}}}}},
+		},
+	}
+
+	out, err := MarshalJSONOrderedAttrMap(doc)
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"pi","target":"xml","content":"version=\"1.0\""},{"type":"elem","name":{"local":"one"},"attrs":[{"zeta":"1"},{"alpha":"2"},{"{xmlns}p":"ns_p"},{"{ns_p}mid":"3"}],"nodes":[{"type":"elem","name":{"local":"two"},"attrs":[{"b":""},{"a":""}]}]}]`, string(out))
+
+	decoded, err := UnmarshalJSONOrderedAttrMap(out)
+	require.NoError(t, err)
+	require.True(t, Equal(doc, decoded, EqualOptions{}))
+	require.Equal(t, doc[1].(Elem).Attrs, decoded[1].(Elem).Attrs)
+
+	_, err = UnmarshalJSONOrderedAttrMap([]byte(`[{"type":"elem","attrs":[{"a":"1","b":"2"}]}]`))
+	require.Error(t, err)
+}
+
+func TestMarshalJSONOrderedAttrMapPrefix(t *testing.T) {
+	doc := Nodes{Elem{
+		Name:   Name{Space: `ns_p`, Local: `one`},
+		Prefix: `p`,
+		Attrs:  []Attr{{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_p`}},
+		Nodes:  Nodes{Elem{Name: Name{Space: `ns_p`, Local: `two`}, Prefix: `p`}},
+	}}
+
+	out, err := MarshalJSONOrderedAttrMap(doc)
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"space":"ns_p","local":"one"},"prefix":"p","attrs":[{"{xmlns}p":"ns_p"}],"nodes":[{"type":"elem","name":{"space":"ns_p","local":"two"},"prefix":"p"}]}]`, string(out))
+
+	decoded, err := UnmarshalJSONOrderedAttrMap(out)
+	require.NoError(t, err)
+	require.Equal(t, doc, decoded)
+}
+
+func TestMarshalJSONOrderedAttrMapRawAttrs(t *testing.T) {
+	src := []byte(`<a b="one&#9;two"/>`)
+	doc, err := Parser{RawAttrs: true}.Parse(src)
+	require.NoError(t, err)
+
+	out, err := MarshalJSONOrderedAttrMap(doc)
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"local":"a"},"attrs":[{"b":"one\ttwo"}],"rawAttrs":["one\u0026#9;two"]}]`, string(out))
+
+	decoded, err := UnmarshalJSONOrderedAttrMap(out)
+	require.NoError(t, err)
+	require.Equal(t, doc, decoded)
+
+	content, err := MarshalOptions{SelfClose: true}.Marshal(decoded)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(content))
+}
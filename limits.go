@@ -0,0 +1,115 @@
+package xt
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+/*
+Resource limits for decoding untrusted input, used by `DecodeSafe` and
+`Parser`. Each limit applies when positive. Limits are checked as tokens are
+read, so decoding fails as soon as a limit is exceeded, without building the
+rest of the tree. The zero value has no limits.
+
+Inputs which are merely large are also limited by the size of the input;
+these limits additionally protect against pathological shapes, such as deep
+nesting or enormous text, which are expensive for the consumers of the tree.
+*/
+type Limits struct {
+	/**
+	Maximum nesting depth of elements. Top-level elements have depth 1.
+	*/
+	MaxDepth int
+
+	/**
+	Maximum total number of nodes of any kind, including elements.
+	*/
+	MaxNodes int
+
+	/**
+	Maximum size of a single text node, in bytes.
+	*/
+	MaxTextSize int
+
+	/**
+	Maximum number of attributes of a single element.
+	*/
+	MaxAttrs int
+}
+
+// Names of the limits in `Limits`, used in `LimitExceededError`.
+const (
+	LimitMaxDepth    = `MaxDepth`
+	LimitMaxNodes    = `MaxNodes`
+	LimitMaxTextSize = `MaxTextSize`
+	LimitMaxAttrs    = `MaxAttrs`
+)
+
+/*
+Returned when decoding exceeds one of the `Limits`. Use `errors.As` to detect
+it.
+*/
+type LimitExceededError struct {
+	/**
+	Which limit was exceeded: one of the `Limit` constants.
+	*/
+	Limit string
+
+	/**
+	Configured value of the limit.
+	*/
+	Max int
+
+	/**
+	Value which exceeded the limit.
+	*/
+	Value int
+}
+
+func (self LimitExceededError) Error() string {
+	switch self.Limit {
+	case LimitMaxDepth:
+		return fmt.Sprintf(`element nesting depth of %d exceeds MaxDepth of %d`, self.Value, self.Max)
+	case LimitMaxNodes:
+		return fmt.Sprintf(`node count of %d exceeds MaxNodes of %d`, self.Value, self.Max)
+	case LimitMaxTextSize:
+		return fmt.Sprintf(`text node of %d bytes exceeds MaxTextSize of %d bytes`, self.Value, self.Max)
+	case LimitMaxAttrs:
+		return fmt.Sprintf(`element with %d attributes exceeds MaxAttrs of %d`, self.Value, self.Max)
+	default:
+		return fmt.Sprintf(`value %d exceeds %v of %d`, self.Value, self.Limit, self.Max)
+	}
+}
+
+/*
+Decodes all remaining nodes from the decoder, like `(*Nodes).Decode`, but
+enforcing the given limits, which makes it suitable for untrusted input. When
+a limit is exceeded, returns `LimitExceededError`, along with the nodes decoded
+so far. Decoder settings, such as `Strict`, are respected. Like
+`Nodes.Decode`, and unlike `Parser`, this decodes CDATA sections as `Text`,
+since the source is not available; `Parser.Limits` enforces the same limits
+while preserving CDATA.
+*/
+func DecodeSafe(dec *xml.Decoder, limits Limits) (Nodes, error) {
+	wrapped := decoder{Decoder: dec, limits: limits}
+	return wrapped.nodes()
+}
+
+func (self Limits) check(limit string, val int) error {
+	var max int
+	switch limit {
+	case LimitMaxDepth:
+		max = self.MaxDepth
+	case LimitMaxNodes:
+		max = self.MaxNodes
+	case LimitMaxTextSize:
+		max = self.MaxTextSize
+	case LimitMaxAttrs:
+		max = self.MaxAttrs
+	}
+
+	if max > 0 && val > max {
+		return LimitExceededError{Limit: limit, Max: max, Value: val}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSafe(t *testing.T) {
+	src := read(t, `simple.xml`)
+
+	decode := func(limits Limits) (Nodes, error) {
+		return DecodeSafe(xml.NewDecoder(bytes.NewReader(src)), limits)
+	}
+
+	doc, err := decode(Limits{})
+	require.NoError(t, err)
+	require.Equal(t, expectedSimple, doc)
+
+	doc, err = decode(Limits{MaxDepth: 3, MaxNodes: 14, MaxTextSize: 20, MaxAttrs: 1})
+	require.NoError(t, err)
+	require.Equal(t, expectedSimple, doc)
+
+	test := func(limits Limits, expected LimitExceededError, msg string) {
+		t.Helper()
+
+		_, err := decode(limits)
+		require.EqualError(t, err, msg)
+
+		var exceeded LimitExceededError
+		require.True(t, errors.As(err, &exceeded))
+		require.Equal(t, expected, exceeded)
+	}
+
+	test(
+		Limits{MaxDepth: 2},
+		LimitExceededError{Limit: LimitMaxDepth, Max: 2, Value: 3},
+		`element nesting depth of 3 exceeds MaxDepth of 2`,
+	)
+	test(
+		Limits{MaxNodes: 13},
+		LimitExceededError{Limit: LimitMaxNodes, Max: 13, Value: 14},
+		`node count of 14 exceeds MaxNodes of 13`,
+	)
+	test(
+		Limits{MaxTextSize: 19},
+		LimitExceededError{Limit: LimitMaxTextSize, Max: 19, Value: 20},
+		`text node of 20 bytes exceeds MaxTextSize of 19 bytes`,
+	)
+
+	src = []byte(`<one two="three" four="five"/>`)
+	test(
+		Limits{MaxAttrs: 1},
+		LimitExceededError{Limit: LimitMaxAttrs, Max: 1, Value: 2},
+		`element with 2 attributes exceeds MaxAttrs of 1`,
+	)
+}
+
+func TestParserLimits(t *testing.T) {
+	_, err := Parser{Limits: Limits{MaxDepth: 1}}.Parse([]byte(`<one><two/></one>`))
+	require.EqualError(t, err, `element nesting depth of 2 exceeds MaxDepth of 1`)
+}
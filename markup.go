@@ -0,0 +1,152 @@
+package xt
+
+import "fmt"
+
+/*
+Namespace of the change markers produced by `MarkupDiff`. When encoding marker
+attributes, both `MarshalOptions` and `encoding/xml` derive the prefix "xt"
+from it, unless a different prefix is declared in scope.
+*/
+const NamespaceXt = `https://github.com/purelabio/xt`
+
+var (
+	markerAdded   = Name{Space: NamespaceXt, Local: `added`}
+	markerRemoved = Name{Space: NamespaceXt, Local: `removed`}
+)
+
+/*
+Compares two trees and returns a merged tree annotated with change markers,
+suitable for rendering a review of the changes. Sibling sequences are aligned
+via the longest common subsequence; elements are aligned when they have the
+same name and attributes, and are compared recursively. Other nodes are aligned
+only when equal.
+
+Unaligned elements are included with an added attribute `xt:added="true"` or
+`xt:removed="true"`. Other unaligned nodes, such as text or comments, are
+wrapped in "added" or "removed" elements in the same namespace. Removed nodes
+precede added nodes at the same position. For example, with prefixes shown for
+readability:
+
+	a:  <list><item id="1">one</item><item id="2">two</item></list>
+	b:  <list><item id="1">uno</item><item id="3">three</item></list>
+
+	<list xmlns:xt="https://github.com/purelabio/xt">
+		<item id="1"><xt:removed>one</xt:removed><xt:added>uno</xt:added></item>
+		<item id="2" xt:removed="true">two</item>
+		<item id="3" xt:added="true">three</item>
+	</list>
+
+The "xt" namespace is `NamespaceXt`. Returns an error if either tree already
+uses this namespace, since markers would be ambiguous.
+*/
+func MarkupDiff(a, b Nodes) (Nodes, error) {
+	for _, nodes := range []Nodes{a, b} {
+		err := checkNoMarkers(nodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return markupNodes(a, b), nil
+}
+
+func checkNoMarkers(nodes Nodes) (err error) {
+	walkPath(nodes, nil, func(path Path, node Node) {
+		elem, ok := nodeElem(node)
+		if !ok || err != nil {
+			return
+		}
+		if elem.Name.Space == NamespaceXt {
+			err = fmt.Errorf(`can't mark up diff: element at %v uses the reserved namespace %q`, path, NamespaceXt)
+			return
+		}
+		for _, attr := range elem.Attrs {
+			if attr.Name.Space == NamespaceXt {
+				err = fmt.Errorf(`can't mark up diff: attribute of element at %v uses the reserved namespace %q`, path, NamespaceXt)
+				return
+			}
+		}
+	})
+	return
+}
+
+func markupNodes(a, b Nodes) Nodes {
+	table := lcsTable(a, b)
+	out := make(Nodes, 0, len(b))
+
+	indA, indB := 0, 0
+	for indA < len(a) || indB < len(b) {
+		switch {
+		case indA < len(a) && indB < len(b) && isAligned(a[indA], b[indB]):
+			out = append(out, markupAligned(a[indA], b[indB]))
+			indA++
+			indB++
+
+		case indA < len(a) && (indB == len(b) || table[indA+1][indB] >= table[indA][indB+1]):
+			out = append(out, markNode(a[indA], markerRemoved))
+			indA++
+
+		default:
+			out = append(out, markNode(b[indB], markerAdded))
+			indB++
+		}
+	}
+	return out
+}
+
+/*
+Builds the table of longest common subsequence lengths for the suffixes of the
+sequences: `table[indA][indB]` is the LCS length of `a[indA:]` and `b[indB:]`.
+*/
+func lcsTable(a, b Nodes) [][]int {
+	table := make([][]int, len(a)+1)
+	for ind := range table {
+		table[ind] = make([]int, len(b)+1)
+	}
+
+	for indA := len(a) - 1; indA >= 0; indA-- {
+		for indB := len(b) - 1; indB >= 0; indB-- {
+			if isAligned(a[indA], b[indB]) {
+				table[indA][indB] = table[indA+1][indB+1] + 1
+			} else if table[indA+1][indB] >= table[indA][indB+1] {
+				table[indA][indB] = table[indA+1][indB]
+			} else {
+				table[indA][indB] = table[indA][indB+1]
+			}
+		}
+	}
+	return table
+}
+
+func isAligned(a, b Node) bool {
+	elemA, okA := nodeElem(a)
+	elemB, okB := nodeElem(b)
+	if okA || okB {
+		return okA && okB &&
+			elemA.Name == elemB.Name &&
+			EqualOptions{RespectPrefixes: true}.attrs(elemA.Attrs, elemB.Attrs)
+	}
+	return Equal(Nodes{a}, Nodes{b}, EqualOptions{RespectPrefixes: true})
+}
+
+func markupAligned(a, b Node) Node {
+	elemA, ok := nodeElem(a)
+	if !ok {
+		return b
+	}
+	elemB, _ := nodeElem(b)
+
+	out := *elemB
+	out.Nodes = markupNodes(elemA.Nodes, elemB.Nodes)
+	return out
+}
+
+func markNode(node Node, marker Name) Node {
+	elem, ok := nodeElem(node)
+	if !ok {
+		return Elem{Name: marker, Nodes: Nodes{node}}
+	}
+
+	out := *elem
+	out.Attrs = append(append(make([]Attr, 0, len(elem.Attrs)+1), elem.Attrs...), Attr{Name: marker, Value: `true`})
+	return out
+}
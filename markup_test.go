@@ -0,0 +1,44 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkupDiff(t *testing.T) {
+	a, err := Parser{}.Parse([]byte(`<list><item id="1">one</item><item id="2">two</item><!-- four --></list>`))
+	require.NoError(t, err)
+
+	b, err := Parser{}.Parse([]byte(`<list><item id="1">uno</item><item id="3">three</item><!-- four --></list>`))
+	require.NoError(t, err)
+
+	out, err := MarkupDiff(a, b)
+	require.NoError(t, err)
+
+	list := out[0].(Elem)
+	require.Equal(t, Elem{
+		Name:  Name{Local: `item`},
+		Attrs: []Attr{{Name: Name{Local: `id`}, Value: `2`}, {Name: markerRemoved, Value: `true`}},
+		Nodes: Nodes{Text(`two`)},
+	}, list.Nodes[1])
+
+	xml, err := MarshalOptions{}.Marshal(out)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		`<list><item id="1"><removed xmlns="https://github.com/purelabio/xt">one</removed><added xmlns="https://github.com/purelabio/xt">uno</added></item><item id="2" xmlns:xt="https://github.com/purelabio/xt" xt:removed="true">two</item><item id="3" xmlns:xt="https://github.com/purelabio/xt" xt:added="true">three</item><!-- four --></list>`,
+		string(xml),
+	)
+
+	same, err := MarkupDiff(a, a)
+	require.NoError(t, err)
+	require.Equal(t, a, same)
+}
+
+func TestMarkupDiffReserved(t *testing.T) {
+	doc := Nodes{Elem{Name: Name{Local: `one`}, Nodes: Nodes{Elem{Name: markerAdded}}}}
+
+	_, err := MarkupDiff(nil, doc)
+	require.EqualError(t, err, `can't mark up diff: element at nodes[0].nodes[0] uses the reserved namespace "https://github.com/purelabio/xt"`)
+}
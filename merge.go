@@ -0,0 +1,141 @@
+package xt
+
+/*
+Deep-merges two elements, such as a base configuration and an overlay, with
+the override taking precedence. The inputs are not modified, but the result
+may share memory with them. The semantics are:
+
+	The result has the name of `base`.
+
+	Attributes of `override` replace the base attributes with the same name,
+	keeping their position. Other override attributes are appended.
+
+	When `override` has no child nodes, the base child nodes are kept. When
+	either element has no child elements, such as for text values, the child
+	nodes of `override` replace the base child nodes.
+
+	Otherwise, child elements are matched by name and, when `keyAttr` is
+	non-zero and the child has that attribute, by the attribute's value. The
+	matched children are merged recursively, in the position of the base
+	child. Unmatched override children are appended. Other base nodes, such
+	as comments or whitespace, are kept, and other override nodes are
+	dropped.
+
+Repeated elements with the same name and key are matched by position: the
+first override occurrence is merged into the first base occurrence, and so on.
+Excess override occurrences are appended. For example, with `keyAttr` "id":
+
+	<config><item id="1" a="1"/><item id="2" a="2"/><name>one</name></config>
+	+
+	<config><item id="2" a="3"/><item id="3"/><name>two</name></config>
+	=
+	<config><item id="1" a="1"/><item id="2" a="3"/><name>two</name><item id="3"/></config>
+*/
+func MergeElems(base, override Elem, keyAttr Name) Elem {
+	out := base
+	out.Attrs = mergeAttrs(base.Attrs, override.Attrs)
+
+	if len(override.Nodes) == 0 {
+		return out
+	}
+	if !hasElems(base.Nodes) || !hasElems(override.Nodes) {
+		out.Nodes = override.Nodes
+		return out
+	}
+
+	matched := make([]bool, len(override.Nodes))
+	seen := map[mergeKey]int{}
+	out.Nodes = make(Nodes, 0, len(base.Nodes))
+
+	for _, node := range base.Nodes {
+		elem, ok := nodeElem(node)
+		if !ok {
+			out.Nodes = append(out.Nodes, node)
+			continue
+		}
+
+		key := elem.mergeKey(keyAttr)
+		ind := nthMergeMatch(override.Nodes, key, keyAttr, seen[key])
+		seen[key]++
+		if ind < 0 {
+			out.Nodes = append(out.Nodes, node)
+			continue
+		}
+
+		matched[ind] = true
+		other, _ := nodeElem(override.Nodes[ind])
+		merged := MergeElems(*elem, *other, keyAttr)
+
+		if _, isPtr := node.(*Elem); isPtr {
+			out.Nodes = append(out.Nodes, &merged)
+		} else {
+			out.Nodes = append(out.Nodes, merged)
+		}
+	}
+
+	for ind, node := range override.Nodes {
+		_, ok := nodeElem(node)
+		if ok && !matched[ind] {
+			out.Nodes = append(out.Nodes, node)
+		}
+	}
+	return out
+}
+
+type mergeKey struct {
+	name   Name
+	value  string
+	hasKey bool
+}
+
+func (self Elem) mergeKey(keyAttr Name) mergeKey {
+	out := mergeKey{name: self.Name}
+	if keyAttr != (Name{}) {
+		out.value, out.hasKey = self.attrValue(keyAttr)
+	}
+	return out
+}
+
+// Index of the nth child element with the given key, or -1.
+func nthMergeMatch(nodes Nodes, key mergeKey, keyAttr Name, nth int) int {
+	for ind, node := range nodes {
+		elem, ok := nodeElem(node)
+		if !ok || elem.mergeKey(keyAttr) != key {
+			continue
+		}
+		if nth == 0 {
+			return ind
+		}
+		nth--
+	}
+	return -1
+}
+
+func mergeAttrs(base, override []Attr) []Attr {
+	if len(override) == 0 {
+		return base
+	}
+
+	out := append([]Attr(nil), base...)
+outer:
+	for _, attr := range override {
+		for ind := range out {
+			if out[ind].Name == attr.Name {
+				out[ind].Value = attr.Value
+				continue outer
+			}
+		}
+		out = append(out, attr)
+	}
+	return out
+}
+
+func hasElems(nodes Nodes) bool {
+	for _, node := range nodes {
+		_, ok := nodeElem(node)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
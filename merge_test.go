@@ -0,0 +1,77 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeElems(t *testing.T) {
+	parse := func(src string) Elem {
+		t.Helper()
+		doc, err := Parser{}.Parse([]byte(src))
+		require.NoError(t, err)
+		return doc[0].(Elem)
+	}
+
+	test := func(base, override string, keyAttr Name, expected string) {
+		t.Helper()
+
+		baseElem := parse(base)
+		overrideElem := parse(override)
+
+		out, err := MarshalOptions{SelfClose: true}.Marshal(MergeElems(baseElem, overrideElem, keyAttr))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(out))
+
+		require.Equal(t, parse(base), baseElem, `must not modify the base`)
+		require.Equal(t, parse(override), overrideElem, `must not modify the override`)
+	}
+
+	test(
+		`<config><item id="1" a="1"/><item id="2" a="2"/><name>one</name></config>`,
+		`<config><item id="2" a="3"/><item id="3"/><name>two</name></config>`,
+		Name{Local: `id`},
+		`<config><item id="1" a="1"/><item id="2" a="3"/><name>two</name><item id="3"/></config>`,
+	)
+
+	test(
+		`<config><item id="1" a="1"/><item id="2" a="2"/></config>`,
+		`<config><item id="2" a="3"/></config>`,
+		Name{},
+		`<config><item id="2" a="3"/><item id="2" a="2"/></config>`,
+	)
+
+	test(
+		`<config version="1" mode="dev">
+  <!-- server -->
+  <server host="localhost" port="80">
+    <timeout>10</timeout>
+    <log level="info"/>
+  </server>
+  <feature name="one" enabled="false"/>
+  <feature name="two" enabled="true"/>
+</config>`,
+		`<config mode="prod" region="eu">
+  <server port="443">
+    <timeout>30</timeout>
+    <tls>on</tls>
+  </server>
+  <feature name="one" enabled="true"/>
+  <feature name="three" enabled="true"/>
+</config>`,
+		Name{Local: `name`},
+		`<config version="1" mode="prod" region="eu">
+  <!-- server -->
+  <server host="localhost" port="443">
+    <timeout>30</timeout>
+    <log level="info"/>
+  <tls>on</tls></server>
+  <feature name="one" enabled="true"/>
+  <feature name="two" enabled="true"/>
+<feature name="three" enabled="true"/></config>`,
+	)
+
+	test(`<one two="three">four</one>`, `<one two="five"/>`, Name{}, `<one two="five">four</one>`)
+	test(`<one><two/></one>`, `<one>three</one>`, Name{}, `<one>three</one>`)
+}
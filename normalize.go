@@ -0,0 +1,284 @@
+package xt
+
+import "strings"
+
+// Whitespace characters, as defined by the XML spec.
+const whitespace = " \t\r\n"
+
+/*
+Options for `Nodes.Normalize`. The zero value is valid and applies the default
+normalization.
+*/
+type NormalizeOptions struct {
+	/**
+	Protects elements with significant whitespace, such as `<pre>` or `<code>`.
+	When this returns true for an element, its entire subtree is kept as-is.
+	*/
+	PreserveWhitespaceIn func(Elem) bool
+
+	/**
+	Decides which whitespace is significant. Text nodes in content preserved by
+	the policy are kept as-is. Defaults to `TrimFormatting`.
+	*/
+	Whitespace WhitespacePolicy
+}
+
+/*
+Returns a normalized copy of the nodes, suitable for comparison or compact
+encoding. Leading and trailing whitespace is trimmed from every `Text` node, and
+text nodes that become empty are dropped. This is lossy: whitespace in
+mixed content may be significant, so use `NormalizeOptions` to protect such
+elements.
+*/
+func (self Nodes) Normalize(opts NormalizeOptions) Nodes {
+	return opts.nodes(nil, self)
+}
+
+func (self NormalizeOptions) nodes(parent *Elem, nodes Nodes) Nodes {
+	if nodes == nil {
+		return nil
+	}
+
+	preserve := self.whitespace().Preserve(parent, nodes)
+	out := make(Nodes, 0, len(nodes))
+
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case Text:
+			if preserve {
+				out = append(out, node)
+				break
+			}
+			val := strings.Trim(string(node), whitespace)
+			if val != "" {
+				out = append(out, Text(val))
+			}
+
+		case Elem:
+			out = append(out, self.elem(node))
+
+		case *Elem:
+			if node != nil {
+				elem := self.elem(*node)
+				node = &elem
+			}
+			out = append(out, node)
+
+		case Nodes:
+			out = append(out, self.nodes(parent, node))
+
+		default:
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+func (self NormalizeOptions) elem(elem Elem) Elem {
+	if self.PreserveWhitespaceIn != nil && self.PreserveWhitespaceIn(elem) {
+		return elem
+	}
+	elem.Nodes = self.nodes(&elem, elem.Nodes)
+	return elem
+}
+
+func (self NormalizeOptions) whitespace() WhitespacePolicy {
+	if self.Whitespace != nil {
+		return self.Whitespace
+	}
+	return TrimFormatting{}
+}
+
+/*
+Returns a copy of the nodes without whitespace-only `Text` nodes, such as the
+indentation between elements, at any depth. Text nodes containing any other
+characters are kept exactly, including their surrounding whitespace. Unlike
+`Nodes.Normalize`, this never modifies text, but also drops whitespace-only
+nodes in mixed content, such as the space in `<b>one</b> <i>two</i>`.
+
+Useful before encoding via `Nodes.EncodeIndent` or as JSON, where such nodes
+produce redundant whitespace or bloat.
+*/
+func (self Nodes) StripInsignificantWhitespace() Nodes {
+	return mapNodeLists(self, stripWhitespaceText)
+}
+
+func stripWhitespaceText(nodes Nodes) Nodes {
+	if nodes == nil {
+		return nil
+	}
+
+	out := make(Nodes, 0, len(nodes))
+	for _, node := range nodes {
+		text, ok := node.(Text)
+		if !ok || !isWhitespace(string(text)) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+/*
+Returns a copy of the nodes where every run of adjacent `Text` nodes is merged
+into a single `Text` node with the concatenated content, at any depth. Other
+nodes, including `CData`, are kept as-is and separate runs of text. Useful after
+editing a tree, to obtain the canonical form produced by decoding.
+*/
+func (self Nodes) CoalesceText() Nodes {
+	return mapNodeLists(self, coalesceText)
+}
+
+func coalesceText(nodes Nodes) Nodes {
+	if nodes == nil {
+		return nil
+	}
+
+	out := make(Nodes, 0, len(nodes))
+	for _, node := range nodes {
+		text, ok := node.(Text)
+		if ok {
+			prev, ok := lastText(out)
+			if ok {
+				out[len(out)-1] = prev + text
+				continue
+			}
+		}
+		out = append(out, node)
+	}
+	return out
+}
+
+/*
+Applies the function to the given nodes and to every list of child nodes at
+any depth, including nested `Nodes`, via `Nodes.Map`. Child lists are
+processed before their parents. The function must not modify its input.
+*/
+func mapNodeLists(nodes Nodes, fn func(Nodes) Nodes) Nodes {
+	out, _ := nodes.Map(func(node Node) (Node, error) {
+		switch node := node.(type) {
+		case Elem:
+			node.Nodes = fn(node.Nodes)
+			return node, nil
+		case *Elem:
+			if node != nil {
+				elem := *node
+				elem.Nodes = fn(elem.Nodes)
+				return &elem, nil
+			}
+		case Nodes:
+			return fn(node), nil
+		}
+		return node, nil
+	})
+	return fn(out)
+}
+
+func lastText(nodes Nodes) (Text, bool) {
+	if len(nodes) == 0 {
+		return "", false
+	}
+	val, ok := nodes[len(nodes)-1].(Text)
+	return val, ok
+}
+
+/*
+Returns a copy of the nodes where every attribute value is normalized like a
+non-CDATA attribute in a validating XML parser: leading and trailing whitespace
+is removed, and runs of whitespace are collapsed into single spaces.
+
+By default, attribute values are kept exactly as decoded. Since this package
+doesn't read DTDs, all attributes are treated as CDATA, which preserves their
+whitespace, and `encoding/xml` doesn't apply even the CDATA normalization of
+whitespace characters into spaces. This is correct for round-tripping; use this
+method when the normalized form is preferred.
+*/
+func (self Nodes) NormalizeAttrWhitespace() Nodes {
+	out := self.Clone()
+	normalizeAttrWhitespace(out)
+	return out
+}
+
+// Mutates the given nodes, which must not share memory with other trees.
+func normalizeAttrWhitespace(nodes Nodes) {
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case Elem:
+			node.normalizeAttrWhitespace()
+		case *Elem:
+			if node != nil {
+				node.normalizeAttrWhitespace()
+			}
+		case Nodes:
+			normalizeAttrWhitespace(node)
+		}
+	}
+}
+
+func (self Elem) normalizeAttrWhitespace() {
+	for ind := range self.Attrs {
+		self.Attrs[ind].Value = strings.Join(strings.FieldsFunc(self.Attrs[ind].Value, isWhitespaceRune), ` `)
+	}
+	normalizeAttrWhitespace(self.Nodes)
+}
+
+func isWhitespaceRune(char rune) bool {
+	return strings.ContainsRune(whitespace, char)
+}
+
+/*
+Returns a copy of the nodes where empty elements have a consistent
+representation, regardless of how they were constructed or decoded. When
+`selfClose` is true, empty text nodes are removed from elements containing
+nothing else, leaving them without child nodes. When `selfClose` is false,
+elements without child nodes are given an empty text node.
+
+When encoding via `MarshalOptions` with `SelfClose`, elements without child
+nodes are written as `<one/>`, and elements with an empty text node as
+`<one></one>`, so this determines the output for all empty elements. Other
+encoders, including `encoding/xml`, always write `<one></one>`.
+*/
+func (self Nodes) NormalizeEmptyElements(selfClose bool) Nodes {
+	if self == nil {
+		return nil
+	}
+
+	out := make(Nodes, 0, len(self))
+	for _, node := range self {
+		switch node := node.(type) {
+		case Elem:
+			out = append(out, node.normalizeEmpty(selfClose))
+		case *Elem:
+			if node != nil {
+				elem := node.normalizeEmpty(selfClose)
+				node = &elem
+			}
+			out = append(out, node)
+		case Nodes:
+			out = append(out, node.NormalizeEmptyElements(selfClose))
+		default:
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+func (self Elem) normalizeEmpty(selfClose bool) Elem {
+	if selfClose && isEmptyText(self.Nodes) {
+		self.Nodes = nil
+	} else if !selfClose && len(self.Nodes) == 0 {
+		self.Nodes = Nodes{Text(``)}
+	} else {
+		self.Nodes = self.Nodes.NormalizeEmptyElements(selfClose)
+	}
+	return self
+}
+
+// True if the nodes consist only of empty text nodes, or are empty.
+func isEmptyText(nodes Nodes) bool {
+	for _, node := range nodes {
+		if node != Text(``) {
+			return false
+		}
+	}
+	return true
+}
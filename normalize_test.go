@@ -0,0 +1,184 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	var doc Nodes
+	require.NoError(t, doc.Decode(xml.NewDecoder(bytes.NewReader(read(t, `simple.xml`)))))
+
+	expected := Nodes{
+		Pi{
+			Target:  `xml`,
+			Content: `version="1.0" encoding="utf-8"`,
+		},
+		Elem{
+			Name:  Name{Local: "one"},
+			Attrs: []Attr{{Name: Name{Local: "two"}, Value: "three"}},
+			Nodes: Nodes{
+				Text("five"),
+				Elem{
+					Name:  Name{Local: "six"},
+					Attrs: []Attr{{Name: Name{Local: "seven"}, Value: "eight"}},
+					Nodes: Nodes{
+						Elem{
+							Name:  Name{Local: "nine"},
+							Attrs: []Attr{{Name: Name{Local: "ten"}, Value: "eleven"}},
+							Nodes: Nodes{
+								Text("twelve"),
+								Comment(" thirteen "),
+							},
+						},
+						Text("fourteen"),
+					},
+				},
+				Text("sixteen"),
+				Comment(" seventeen "),
+			},
+		},
+	}
+
+	require.Equal(t, expected, doc.Normalize(NormalizeOptions{}))
+	require.Equal(t, expectedSimple, doc, `must not mutate the original`)
+
+	nested := Nodes{Nodes{Text(` one `), (*Elem)(nil)}, E(`two`).C(Nodes{Text("\n")})}
+	require.Equal(t, Nodes{Nodes{Text(`one`), (*Elem)(nil)}, E(`two`).C(Nodes{})}, nested.Normalize(NormalizeOptions{}))
+}
+
+func TestStripInsignificantWhitespace(t *testing.T) {
+	// `expectedSimple` without the whitespace-only text nodes.
+	nine := expectedSimple[2].(Elem).Nodes[1].(Elem).Nodes[1].(Elem)
+	nine.Nodes = nine.Nodes[:2]
+	six := expectedSimple[2].(Elem).Nodes[1].(Elem)
+	six.Nodes = Nodes{nine, six.Nodes[2]}
+	one := expectedSimple[2].(Elem)
+	one.Nodes = Nodes{one.Nodes[0], six, one.Nodes[2], one.Nodes[3]}
+	expected := Nodes{expectedSimple[0], one}
+
+	doc, err := Parser{}.Parse(read(t, `simple.xml`))
+	require.NoError(t, err)
+
+	require.Equal(t, expected, doc.StripInsignificantWhitespace())
+	require.Equal(t, expectedSimple, doc, `must not mutate the original`)
+
+	ptr := &Elem{Name: Name{Local: `one`}, Nodes: Nodes{Text(" "), Elem{Name: Name{Local: `two`}}, Text(" three ")}}
+	out := Nodes{ptr, Text("\n"), Nodes{Text("\t")}}.StripInsignificantWhitespace()
+	require.Equal(t, Nodes{&Elem{Name: Name{Local: `one`}, Nodes: Nodes{Elem{Name: Name{Local: `two`}}, Text(" three ")}}, Nodes{}}, out)
+	require.Len(t, ptr.Nodes, 3)
+	require.Nil(t, Nodes(nil).StripInsignificantWhitespace())
+}
+
+func TestCoalesceText(t *testing.T) {
+	ptr := &Elem{Name: Name{Local: `two`}, Nodes: Nodes{Text(`three`), Text(``), Text(`four`)}}
+	doc := Nodes{
+		Text(`one`),
+		Elem{
+			Name:  Name{Local: `one`},
+			Nodes: Nodes{Text(`five`), Text(` `), Comment(`six`), Text(`seven`), CData(`eight`), Text(`nine`), Text(`ten`)},
+		},
+		Text(`eleven`),
+		Text(`twelve`),
+		ptr,
+		Nodes{Text(`a`), Text(`b`)},
+	}
+
+	require.Equal(t, Nodes{
+		Text(`one`),
+		Elem{
+			Name:  Name{Local: `one`},
+			Nodes: Nodes{Text(`five `), Comment(`six`), Text(`seven`), CData(`eight`), Text(`nineten`)},
+		},
+		Text(`eleventwelve`),
+		&Elem{Name: Name{Local: `two`}, Nodes: Nodes{Text(`threefour`)}},
+		Nodes{Text(`ab`)},
+	}, doc.CoalesceText())
+
+	require.Len(t, ptr.Nodes, 3, `must not mutate the original`)
+	require.Equal(t, Text(`five`), doc[1].(Elem).Nodes[0])
+	require.Nil(t, Nodes(nil).CoalesceText())
+	require.Equal(t, expectedSimple, expectedSimple.CoalesceText())
+}
+
+func TestNormalizePreserveWhitespaceIn(t *testing.T) {
+	doc := Nodes{
+		Elem{
+			Name: Name{Local: "doc"},
+			Nodes: Nodes{
+				Text("\n  "),
+				Elem{Name: Name{Local: "p"}, Nodes: Nodes{Text("  trimmed  ")}},
+				Text("\n  "),
+				Elem{Name: Name{Local: "code"}, Nodes: Nodes{Text("  spaces matter  ")}},
+				Text("\n"),
+			},
+		},
+	}
+
+	isCode := func(elem Elem) bool { return elem.Name.Local == "code" }
+
+	expected := Nodes{
+		Elem{
+			Name: Name{Local: "doc"},
+			Nodes: Nodes{
+				Elem{Name: Name{Local: "p"}, Nodes: Nodes{Text("trimmed")}},
+				Elem{Name: Name{Local: "code"}, Nodes: Nodes{Text("  spaces matter  ")}},
+			},
+		},
+	}
+
+	require.Equal(t, expected, doc.Normalize(NormalizeOptions{PreserveWhitespaceIn: isCode}))
+}
+
+func TestAttrWhitespace(t *testing.T) {
+	src := "<one two=\"  three \t\r\n four  \"><five six=\"seven\n\"></five></one>"
+
+	doc, err := Parser{}.Parse([]byte(src))
+	require.NoError(t, err)
+
+	elem := doc[0].(Elem)
+	require.Equal(t, "  three \t\n four  ", elem.Attrs[0].Value, `must preserve whitespace as decoded by encoding/xml`)
+	require.Equal(t, "seven\n", elem.Nodes[0].(Elem).Attrs[0].Value)
+
+	norm := doc.NormalizeAttrWhitespace()
+	require.Equal(t, `three four`, norm[0].(Elem).Attrs[0].Value)
+	require.Equal(t, `seven`, norm[0].(Elem).Nodes[0].(Elem).Attrs[0].Value)
+
+	require.Equal(t, "  three \t\n four  ", elem.Attrs[0].Value, `must not mutate the original`)
+}
+
+func TestNormalizeEmptyElements(t *testing.T) {
+	doc := Nodes{Elem{
+		Name: Name{Local: `one`},
+		Nodes: Nodes{
+			Elem{Name: Name{Local: `two`}, Nodes: Nodes{Text(``)}},
+			&Elem{Name: Name{Local: `three`}, Nodes: Nodes{Text(``), Text(``)}},
+			Elem{Name: Name{Local: `four`}},
+			Elem{Name: Name{Local: `five`}, Nodes: Nodes{Text(` `)}},
+		},
+	}}
+
+	test := func(doc Nodes, expected string) {
+		t.Helper()
+		out, err := MarshalOptions{SelfClose: true}.Marshal(doc)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(out))
+	}
+
+	test(doc, `<one><two></two><three></three><four/><five> </five></one>`)
+	test(doc.NormalizeEmptyElements(true), `<one><two/><three/><four/><five> </five></one>`)
+	test(doc.NormalizeEmptyElements(false), `<one><two></two><three></three><four></four><five> </five></one>`)
+
+	require.Equal(t, Nodes{Text(``)}, doc[0].(Elem).Nodes[0].(Elem).Nodes, `must not mutate the original`)
+	require.Nil(t, doc[0].(Elem).Nodes[2].(Elem).Nodes, `must not mutate the original`)
+
+	out, err := xml.Marshal(doc.NormalizeEmptyElements(true))
+	require.NoError(t, err)
+	require.Equal(t, `<one><two></two><three></three><four></four><five> </five></one>`, string(out))
+
+	withNil := Nodes{(*Elem)(nil), E(`one`).C((*Elem)(nil))}
+	require.Equal(t, Nodes{(*Elem)(nil), E(`one`).C((*Elem)(nil))}, withNil.NormalizeEmptyElements(true))
+}
@@ -0,0 +1,168 @@
+package xt
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+/*
+Reserved namespace of namespace declarations. When decoding, `encoding/xml`
+uses this as the `Space` of prefixed declarations such as `xmlns:one="two"`,
+which become `Attr{Name: Name{Space: "xmlns", Local: "one"}, Value: "two"}`.
+Default declarations such as `xmlns="two"` become
+`Attr{Name: Name{Local: "xmlns"}, Value: "two"}`.
+
+Technically, the spec binds the "xmlns" prefix to the URI
+"http://www.w3.org/2000/xmlns/", but `encoding/xml` never resolves it, and
+neither does this package.
+*/
+const NamespaceXMLNS = `xmlns`
+
+/*
+Reserved namespace of the "xml" prefix, used by attributes such as `xml:lang`,
+`xml:space`, `xml:base` and `xml:id`. The prefix is implicitly declared in
+every document. `encoding/xml` resolves it when decoding and writes it back as
+"xml" without a declaration when encoding.
+*/
+const NamespaceXML = `http://www.w3.org/XML/1998/namespace`
+
+/*
+True if the attribute is a namespace declaration, either prefixed such as
+`xmlns:one="two"` or default such as `xmlns="two"`.
+*/
+func IsNamespaceDecl(attr Attr) bool {
+	return attr.Name.Space == NamespaceXMLNS ||
+		(attr.Name.Space == "" && attr.Name.Local == NamespaceXMLNS)
+}
+
+/*
+True if the name belongs to the reserved "xml" namespace, such as `xml:lang`.
+*/
+func IsXMLReserved(name Name) bool {
+	return name.Space == NamespaceXML
+}
+
+/*
+If the attribute is a namespace declaration, returns the declared prefix and
+namespace URI. For default declarations such as `xmlns="two"`, the prefix is
+empty.
+*/
+func (self Attr) DeclaredPrefix() (prefix, uri string, ok bool) {
+	if !IsNamespaceDecl(self) {
+		return "", "", false
+	}
+	if self.Name.Space == NamespaceXMLNS {
+		return self.Name.Local, self.Value, true
+	}
+	return "", self.Value, true
+}
+
+/*
+Annotates the elements with the namespace prefixes declared in the document, so
+that encoding reproduces prefixed names such as `<one:two>`, rather than
+declaring the default namespace on every element. For every element, sets
+`Elem.Prefix` to the prefix bound to its namespace by the innermost declaration
+on the element or its ancestors, or to "" when the namespace is the default
+one or isn't bound to any prefix. Both `xml.Marshal` and `MarshalOptions`
+respect the prefixes.
+
+Modifies the nodes in-place, including elements stored as `Elem` in the
+slices. Returns an error if a prefix is declared with an empty namespace URI,
+which XML 1.0 namespaces forbid.
+*/
+func (self Nodes) ResolveNamespaces() error {
+	return resolveNamespaces(self, nil)
+}
+
+func resolveNamespaces(nodes Nodes, scope []nsDecl) error {
+	for ind, node := range nodes {
+		var err error
+		switch node := node.(type) {
+		case Elem:
+			err = node.resolveNamespaces(scope)
+			nodes[ind] = node
+		case *Elem:
+			if node != nil {
+				err = node.resolveNamespaces(scope)
+			}
+		case Nodes:
+			err = resolveNamespaces(node, scope)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *Elem) resolveNamespaces(scope []nsDecl) error {
+	for _, attr := range self.Attrs {
+		prefix, uri, ok := attr.DeclaredPrefix()
+		if !ok {
+			continue
+		}
+		if prefix != "" && uri == "" {
+			return fmt.Errorf(`invalid declaration of namespace prefix %q with empty URI in <%s>`, prefix, self.Name.clark())
+		}
+		scope = append(scope, nsDecl{prefix, uri})
+	}
+
+	self.Prefix = ""
+	if self.Name.Space != "" {
+		self.Prefix = scopePrefix(scope, self.Name.Space)
+	}
+	return resolveNamespaces(self.Nodes, scope)
+}
+
+/*
+Finds the prefix bound to the namespace URI by the innermost declaration in
+scope which hasn't been overridden. Empty prefix stands for the default
+namespace or for an unbound URI.
+*/
+func scopePrefix(scope []nsDecl, uri string) string {
+outer:
+	for ind := len(scope) - 1; ind >= 0; ind-- {
+		decl := scope[ind]
+		if decl.uri != uri {
+			continue
+		}
+		for _, later := range scope[ind+1:] {
+			if later.prefix == decl.prefix {
+				continue outer
+			}
+		}
+		return decl.prefix
+	}
+	return ""
+}
+
+/*
+Converts attributes for `xml.Encoder`. Prefixed namespace declarations are
+turned into unqualified attributes such as `xmlns:one`, which the encoder
+writes verbatim. Otherwise, the encoder would treat "xmlns" as an arbitrary
+namespace URI and declare a new prefix for it.
+*/
+func attrsToEncode(attrs []Attr) []xml.Attr {
+	if !hasPrefixedDecl(attrs) {
+		return attrsTo(attrs)
+	}
+
+	out := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		name := xml.Name(attr.Name)
+		if name.Space == NamespaceXMLNS {
+			name = xml.Name{Local: NamespaceXMLNS + `:` + name.Local}
+		}
+		out = append(out, xml.Attr{Name: name, Value: attr.Value})
+	}
+	return out
+}
+
+func hasPrefixedDecl(attrs []Attr) bool {
+	for _, attr := range attrs {
+		if attr.Name.Space == NamespaceXMLNS {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNamespaceDecl(t *testing.T) {
+	aliased := expectedNsAliased[2].(Elem)
+	require.True(t, IsNamespaceDecl(aliased.Attrs[0]))
+	require.False(t, IsNamespaceDecl(aliased.Attrs[1]))
+
+	inlined := expectedNsInlined[2].(Elem)
+	require.True(t, IsNamespaceDecl(inlined.Attrs[0]))
+	require.False(t, IsNamespaceDecl(inlined.Attrs[1]))
+
+	require.False(t, IsNamespaceDecl(Attr{Name: Name{Space: `ns`, Local: `xmlns`}}))
+}
+
+func TestDeclaredPrefix(t *testing.T) {
+	test := func(attr Attr, expPrefix, expUri string, expOk bool) {
+		t.Helper()
+		prefix, uri, ok := attr.DeclaredPrefix()
+		require.Equal(t, expPrefix, prefix)
+		require.Equal(t, expUri, uri)
+		require.Equal(t, expOk, ok)
+	}
+
+	test(expectedNsAliased[2].(Elem).Attrs[0], `outer`, `ns_outer`, true)
+	test(expectedNsInlined[2].(Elem).Attrs[0], ``, `ns_outer`, true)
+	test(expectedNsAliased[2].(Elem).Attrs[1], ``, ``, false)
+}
+
+func TestNsEncodeAliased(t *testing.T) {
+	src := read(t, `ns_aliased.xml`)
+
+	var doc Nodes
+	require.NoError(t, doc.Decode(xml.NewDecoder(bytes.NewReader(src))))
+	require.Equal(t, expectedNsAliased, doc)
+
+	content, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(read(t, `ns_aliased_out.xml`)), string(content))
+}
+
+func TestResolveNamespaces(t *testing.T) {
+	src := read(t, `ns_aliased.xml`)
+
+	var doc Nodes
+	require.NoError(t, doc.Decode(xml.NewDecoder(bytes.NewReader(src))))
+	require.NoError(t, doc.ResolveNamespaces())
+
+	one := doc[2].(Elem)
+	require.Equal(t, `outer`, one.Prefix)
+	require.Equal(t, `outer`, one.Nodes[1].(Elem).Prefix)
+	require.Equal(t, `inner`, one.Nodes[3].(Elem).Prefix)
+	require.True(t, Equal(expectedNsAliased, doc, EqualOptions{}))
+
+	expected := string(read(t, `ns_resolved_out.xml`))
+
+	content, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+
+	content, err = MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+
+	content, err = json.Marshal(doc)
+	require.NoError(t, err)
+	var decoded Nodes
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	content, err = xml.Marshal(decoded)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+
+	content, err = doc.MarshalBinary()
+	require.NoError(t, err)
+	decoded = nil
+	require.NoError(t, decoded.UnmarshalBinary(content))
+	require.Equal(t, doc, decoded)
+}
+
+func TestResolveNamespacesScope(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<a:one xmlns:a="x" xmlns:b="y"><b:two xmlns="y"><three xmlns:b="z"><b:four xmlns:c="x"/></three></b:two><b:five/></a:one>`))
+	require.NoError(t, err)
+	require.NoError(t, doc.ResolveNamespaces())
+
+	one := doc[0].(Elem)
+	two := one.Nodes[0].(Elem)
+	three := two.Nodes[0].(Elem)
+	four := three.Nodes[0].(Elem)
+
+	require.Equal(t, `a`, one.Prefix)
+	require.Equal(t, ``, two.Prefix, `default namespace is the innermost binding`)
+	require.Equal(t, ``, three.Prefix)
+	require.Equal(t, `b`, four.Prefix)
+	require.Equal(t, `b`, one.Nodes[1].(Elem).Prefix)
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<a:one xmlns:a="x" xmlns:b="y"><two xmlns="y"><three xmlns="y" xmlns:b="z"><b:four xmlns:c="x"></b:four></three></two><b:five></b:five></a:one>`, string(out))
+
+	moved := Nodes{Elem{Name: Name{Space: `x`, Local: `one`}, Prefix: `a`}}
+	out, err = MarshalOptions{}.Marshal(moved)
+	require.NoError(t, err)
+	require.Equal(t, `<a:one xmlns:a="x"></a:one>`, string(out))
+
+	invalid := Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{{Name: Name{Space: NamespaceXMLNS, Local: `a`}}}}}
+	require.EqualError(t, invalid.ResolveNamespaces(), `invalid declaration of namespace prefix "a" with empty URI in <one>`)
+}
+
+func TestXmlReserved(t *testing.T) {
+	src := []byte(`<one xml:lang="en" lang="two"></one>`)
+
+	var doc Nodes
+	require.NoError(t, doc.Decode(xml.NewDecoder(bytes.NewReader(src))))
+
+	expected := Nodes{
+		Elem{
+			Name: Name{Local: `one`},
+			Attrs: []Attr{
+				{Name: Name{Space: NamespaceXML, Local: `lang`}, Value: `en`},
+				{Name: Name{Local: `lang`}, Value: `two`},
+			},
+		},
+	}
+	require.Equal(t, expected, doc)
+
+	elem := doc[0].(Elem)
+	require.True(t, IsXMLReserved(elem.Attrs[0].Name))
+	require.False(t, IsXMLReserved(elem.Attrs[1].Name))
+	require.False(t, IsXMLReserved(elem.Name))
+
+	content, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(content))
+}
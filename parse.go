@@ -0,0 +1,679 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// XML versions supported by `Parser`.
+const (
+	Version10 = `1.0`
+	Version11 = `1.1`
+)
+
+/*
+Configurable XML parser. The zero value is valid and parses XML 1.0, detecting
+XML 1.1 from the XML declaration.
+*/
+type Parser struct {
+	/**
+	XML version to assume, either `Version10` or `Version11`. When empty, the
+	version is detected from the XML declaration, defaulting to 1.0.
+
+	`encoding/xml` only supports XML 1.0. For XML 1.1, the parser additionally:
+
+		* Normalizes the line endings NEL (U+0085) and LS (U+2028) everywhere,
+		  including comments, CDATA sections and processing instructions.
+		* Allows character references to the control characters U+0001–U+001F.
+		* Rejects the control characters U+007F–U+009F other than NEL, unless
+		  written as character references.
+
+	Internally, references to U+0001–U+001F are decoded via placeholders from
+	the private use range U+10FF01–U+10FF1F; documents containing those
+	characters literally are not supported in XML 1.1 mode. Names are still
+	checked by the XML 1.0 rules of `encoding/xml`, so names using characters
+	which only XML 1.1 allows are rejected. The XML declaration is preserved
+	as-is.
+	*/
+	Version string
+
+	/**
+	Resource limits for untrusted input. See `Limits`.
+	*/
+	Limits Limits
+
+	/**
+	Intern attribute values, so that repeated values, such as enums, booleans
+	or URIs, share the same memory. Reduces the memory retained by
+	data-heavy documents at the cost of a map lookup per attribute. The pool
+	is local to each call, and doesn't outlive the parse.
+	*/
+	InternAttrValues bool
+
+	/**
+	Parse HTML-ish input leniently, via the non-strict mode of `xml.Decoder`.
+	Named HTML entities such as `&nbsp;` or `&copy;` are decoded into their
+	characters via `xml.HTMLEntity`, unknown entities and stray "&" are kept
+	as-is, void elements such as `<br>` are closed via `xml.HTMLAutoClose`,
+	and attributes without values or quotes are accepted. Decoded entities
+	can't be told apart from literal characters after parsing, so re-encoding
+	produces characters rather than entity references.
+	*/
+	Lenient bool
+
+	/**
+	Share the memory of identical subtrees, such as repeated records in
+	data-heavy documents. Elements are compared by a canonical key built from
+	their names, attributes and content, and every repeated element reuses the
+	`Attrs` and `Nodes` slices of its first occurrence. Reduces the memory
+	retained by highly repetitive documents, at the cost of building the key
+	for every element during parsing.
+
+	Because the slices are shared, modifying a shared subtree in-place affects
+	all of its occurrences. Trees parsed in this mode should be treated as
+	immutable; copy them via `Nodes.Clone` before modifying.
+	*/
+	ShareSubtrees bool
+
+	/**
+	Preserve references to general entities in text, such as `&copy;`, as
+	`EntityRef` nodes, rather than failing on entities unknown to
+	`xml.Decoder`, or expanding entities known via `Lenient`. The predefined
+	entities such as `&amp;` and character references such as `&#169;` are
+	still decoded into text. References in attribute values are not affected.
+	*/
+	PreserveEntityRefs bool
+
+	/**
+	Decode the document type declaration, such as `<!DOCTYPE html>`, as
+	`Doctype` with its parts parsed out, rather than as `Decl`. Malformed
+	doctypes and other declarations are still decoded as `Decl`.
+	*/
+	DecodeDoctype bool
+
+	/**
+	Record the source text of attribute values in `Elem.RawAttrs`, alongside
+	the decoded values in `Elem.Attrs`. `encoding/xml` decodes character and
+	entity references in attribute values, and normalizes line endings, so
+	`a="one&#x9;two"` and a literal tab are indistinguishable after decoding.
+	When encoding via `MarshalOptions`, each raw value is written verbatim as
+	long as it still decodes to the attribute value; values modified after
+	parsing are escaped as usual. The raw text is only recorded when every
+	attribute can be found in the source, which excludes unquoted attributes
+	accepted by `Lenient`.
+	*/
+	RawAttrs bool
+
+	/**
+	Converts documents in encodings other than UTF-8, as declared by the XML
+	declaration, into UTF-8. Same signature as `xml.Decoder.CharsetReader`,
+	and compatible with `charset.NewReaderLabel` from
+	"golang.org/x/net/html/charset"; see the "xtcharset" subpackage. When
+	nil, such documents fail to parse, since `encoding/xml` only supports
+	UTF-8. The input is converted up front, and the XML declaration is
+	preserved as-is, including its "encoding".
+	*/
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+}
+
+/*
+Parses an entire XML document or fragment with the default settings of
+`Parser`. Shortcut for `Parser{}.Parse(src)`. Unlike `(*Nodes).Decode` with a
+plain `xml.Decoder`, this preserves CDATA sections, supports XML 1.1, and
+reports the enclosing elements in errors.
+*/
+func Parse(src []byte) (Nodes, error) {
+	return Parser{}.Parse(src)
+}
+
+// Same as `Parse`, but for a string.
+func ParseString(src string) (Nodes, error) {
+	return Parse([]byte(src))
+}
+
+// Parses an entire XML document or fragment.
+func (self Parser) Parse(src []byte) (Nodes, error) {
+	out, _, err := self.parse(src, false)
+	return out, err
+}
+
+/*
+Diagnostic variant of `Parser.Parse` which also returns the raw tokens emitted
+by `xml.Decoder`, copied via `xml.CopyToken`. Comparing the tokens with the
+resulting tree helps to diagnose round-trip divergences. For XML 1.1 input,
+the tokens reflect the input as rewritten for `encoding/xml`. With
+`Parser.PreserveEntityRefs`, entity references in text tokens are restored to
+their source form, such as `&copy;`. Parsing via
+`Parser.Parse` doesn't record tokens, and isn't slowed down by this.
+*/
+func (self Parser) ParseWithTokens(src []byte) (Nodes, []xml.Token, error) {
+	return self.parse(src, true)
+}
+
+func (self Parser) parse(src []byte, record bool) (Nodes, []xml.Token, error) {
+	if self.CharsetReader != nil {
+		var err error
+		src, err = self.convertCharset(src)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	version := self.Version
+	if version == "" {
+		version = detectVersion(src)
+	}
+
+	var rewritten bool
+	switch version {
+	case Version10:
+	case Version11:
+		var err error
+		src, rewritten, err = prepareVersion11(src)
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf(`unsupported XML version %q`, version)
+	}
+
+	if self.PreserveEntityRefs {
+		src = prepareEntityRefs(src)
+	}
+
+	dec := decoder{
+		Decoder: xml.NewDecoder(bytes.NewReader(src)),
+		src:     src,
+		limits:  self.Limits,
+		record:  record,
+		doctype: self.DecodeDoctype,
+		raw:     self.RawAttrs,
+	}
+	if self.InternAttrValues {
+		dec.values = map[string]string{}
+	}
+	if self.ShareSubtrees {
+		dec.shared = &subtreePool{ids: map[string]int{}}
+	}
+	if self.CharsetReader != nil {
+		// The input is already converted. Offsets must match the input for
+		// detecting CDATA sections, so the decoder must not convert it again.
+		dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	}
+	if self.Lenient {
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+	}
+
+	out, err := dec.nodes()
+	if err != nil {
+		return out, dec.tokens, err
+	}
+
+	if version == Version11 {
+		restoreVersion11(out, rewritten)
+	}
+	if self.PreserveEntityRefs {
+		out = restoreEntityRefs(out)
+		restoreEntityTokens(dec.tokens)
+	}
+	return out, dec.tokens, nil
+}
+
+/*
+Converts the input into UTF-8 via `Parser.CharsetReader`, according to the
+encoding in the XML declaration, if any.
+*/
+func (self Parser) convertCharset(src []byte) ([]byte, error) {
+	end := bytes.Index(src, []byte(`?>`))
+	if end < 0 {
+		return src, nil
+	}
+
+	label := declEncoding(string(src[:end+len(`?>`)]))
+	if label == "" || strings.EqualFold(label, `utf-8`) {
+		return src, nil
+	}
+
+	reader, err := self.CharsetReader(label, bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+/*
+Decodes nodes like `Nodes.Decode`, `DecodeToken` and `Elem.UnmarshalXML`, but
+with additional options used by `Parser`. Also detects CDATA sections, which
+`xml.Decoder` reports as regular text, by peeking at the source at the offset
+of each token.
+*/
+type decoder struct {
+	*xml.Decoder
+	src     []byte
+	offset  int64
+	limits  Limits
+	stack   []Name
+	count   int
+	record  bool
+	tokens  []xml.Token
+	values  map[string]string
+	shared  *subtreePool
+	doctype bool
+	raw     bool
+
+	// Pool index of the last decoded element, when sharing subtrees.
+	sharedID int
+}
+
+// Same as `(*xml.Decoder).Token`, but also records tokens when enabled.
+func (self *decoder) Token() (xml.Token, error) {
+	self.offset = self.InputOffset()
+	tok, err := self.Decoder.Token()
+	if err == nil && self.record {
+		self.tokens = append(self.tokens, xml.CopyToken(tok))
+	}
+	return tok, err
+}
+
+func (self *decoder) nodes() (Nodes, error) {
+	var out Nodes
+	for {
+		tok, err := self.Token()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+
+		node, err := self.node(tok)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, node)
+	}
+}
+
+func (self *decoder) node(tok xml.Token) (Node, error) {
+	self.count++
+	err := self.limits.check(LimitMaxNodes, self.count)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case xml.CharData:
+		err := self.limits.check(LimitMaxTextSize, len(tok))
+		if err != nil {
+			return nil, err
+		}
+		if self.isCData() {
+			return CData(tok), nil
+		}
+		return Text(tok), nil
+
+	case xml.StartElement:
+		return self.elem(tok)
+
+	case xml.Directive:
+		if self.doctype {
+			doctype, ok := Decl(tok).Doctype()
+			if ok {
+				return doctype, nil
+			}
+		}
+	}
+
+	var out Node
+	err = DecodeToken(self.Decoder, tok, &out)
+	return out, err
+}
+
+func (self *decoder) elem(start xml.StartElement) (Elem, error) {
+	out := Elem{Name: Name(start.Name), Attrs: attrsFrom(start.Attr)}
+	if self.raw {
+		out.RawAttrs = scanRawAttrs(self.src[self.offset:self.InputOffset()], len(out.Attrs))
+	}
+
+	self.stack = append(self.stack, out.Name)
+	defer func() { self.stack = self.stack[:len(self.stack)-1] }()
+
+	err := self.limits.check(LimitMaxDepth, len(self.stack))
+	if err == nil {
+		err = self.limits.check(LimitMaxAttrs, len(out.Attrs))
+	}
+	if err != nil {
+		return out, err
+	}
+
+	if self.values != nil {
+		self.intern(out.Attrs)
+	}
+
+	var key []byte
+	if self.shared != nil {
+		key = appendSubtreeHead(key, out)
+	}
+
+	for {
+		tok, err := self.Token()
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return out, self.stackError(err)
+		}
+
+		_, ok := tok.(xml.EndElement)
+		if ok {
+			return self.share(out, key), nil
+		}
+
+		node, err := self.node(tok)
+		if err != nil {
+			return out, err
+		}
+		out.Nodes = append(out.Nodes, node)
+
+		if self.shared != nil {
+			key = appendSubtreeNode(key, node, self.sharedID)
+		}
+	}
+}
+
+func (self *decoder) share(elem Elem, key []byte) Elem {
+	if self.shared == nil {
+		return elem
+	}
+	elem, self.sharedID = self.shared.share(elem, key)
+	return elem
+}
+
+// Wraps a decoder error with the stack of the currently open elements.
+func (self *decoder) stackError(err error) error {
+	return ElementStackError{Stack: append([]Name(nil), self.stack...), Err: err}
+}
+
+// True if the last token started with a CDATA section.
+func (self *decoder) isCData() bool {
+	return self.offset < int64(len(self.src)) &&
+		bytes.HasPrefix(self.src[self.offset:], []byte(`<![CDATA[`))
+}
+
+func (self *decoder) intern(attrs []Attr) {
+	for ind := range attrs {
+		val := attrs[ind].Value
+		prev, ok := self.values[val]
+		if ok {
+			attrs[ind].Value = prev
+		} else {
+			self.values[val] = val
+		}
+	}
+}
+
+// Offset of the first private-use placeholder for XML 1.1 control characters.
+const placeholderBase = 0x10FF00
+
+/*
+Returns the version from the XML declaration at the start of the input,
+defaulting to 1.0.
+*/
+func detectVersion(src []byte) string {
+	_, val := declVersion(src)
+	if val == "" {
+		return Version10
+	}
+	return val
+}
+
+/*
+Finds the value of the "version" pseudo-attribute in the XML declaration at the
+start of the input, returning its offset and value.
+*/
+func declVersion(src []byte) (int, string) {
+	start := 0
+	if bytes.HasPrefix(src, utf8Bom) {
+		start = len(utf8Bom)
+	}
+	if !bytes.HasPrefix(src[start:], []byte(`<?xml`)) {
+		return 0, ""
+	}
+
+	end := bytes.Index(src[start:], []byte(`?>`))
+	if end < 0 {
+		return 0, ""
+	}
+	decl := string(src[start : start+end])
+
+	offset, val := declPseudoAttr(decl, `version`)
+	if offset < 0 {
+		return 0, ""
+	}
+	return start + offset, val
+}
+
+/*
+Finds the value of the given pseudo-attribute, such as "version" or "encoding",
+in the text of an XML declaration. Returns the offset of the value in the
+declaration and the value, or -1 if not found.
+*/
+func declPseudoAttr(decl string, name string) (int, string) {
+	ind := strings.Index(decl, name)
+	if ind < 0 {
+		return -1, ""
+	}
+	rest := strings.TrimLeft(decl[ind+len(name):], whitespace)
+	if !strings.HasPrefix(rest, `=`) {
+		return -1, ""
+	}
+	rest = strings.TrimLeft(rest[1:], whitespace)
+	if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+		return -1, ""
+	}
+
+	quote := rest[0]
+	rest = rest[1:]
+	size := strings.IndexByte(rest, quote)
+	if size < 0 {
+		return -1, ""
+	}
+	return len(decl) - len(rest), rest[:size]
+}
+
+var utf8Bom = []byte("\xef\xbb\xbf")
+
+/*
+Rewrites an XML 1.1 document into a form accepted by `encoding/xml`. Also
+reports whether the version in the XML declaration was rewritten. Also see
+`restoreVersion11`.
+*/
+func prepareVersion11(src []byte) ([]byte, bool, error) {
+	ind := bytes.IndexFunc(src, isRestrictedLiteral)
+	if ind >= 0 {
+		char, _ := utf8.DecodeRune(src[ind:])
+		return nil, false, &xml.SyntaxError{
+			Msg:  fmt.Sprintf(`character %U must be written as a character reference in XML 1.1`, char),
+			Line: 1 + bytes.Count(src[:ind], []byte("\n")),
+		}
+	}
+
+	out := make([]byte, 0, len(src))
+
+	offset, version := declVersion(src)
+	rewritten := version == Version11
+	if rewritten {
+		out = append(out, src[:offset]...)
+		out = append(out, Version10...)
+		src = src[offset+len(version):]
+	}
+
+	for len(src) > 0 {
+		// Sections where character references are not recognized.
+		if skip := rawSectionLen(src); skip > 0 {
+			out = appendLines11(out, src[:skip])
+			src = src[skip:]
+			continue
+		}
+
+		switch {
+		case lineEnd11Len(src) > 0:
+			out = append(out, '\n')
+			src = src[lineEnd11Len(src):]
+
+		case bytes.HasPrefix(src, []byte(`&#`)):
+			char, size := parseCharRef(src)
+			if size > 0 && isRestrictedChar(char) {
+				out = append(out, string(rune(placeholderBase+char))...)
+				src = src[size:]
+				continue
+			}
+			out = append(out, src[0])
+			src = src[1:]
+
+		default:
+			out = append(out, src[0])
+			src = src[1:]
+		}
+	}
+
+	return out, rewritten, nil
+}
+
+/*
+Length of a line ending at the start of the input which XML 1.1 normalizes,
+but `encoding/xml` doesn't: NEL (U+0085), CR followed by NEL, or LS (U+2028).
+Returns 0 if there's no such line ending.
+*/
+func lineEnd11Len(src []byte) int {
+	for _, val := range [...]string{"\r\u0085", "\u0085", "\u2028"} {
+		if bytes.HasPrefix(src, []byte(val)) {
+			return len(val)
+		}
+	}
+	return 0
+}
+
+// Appends the input, normalizing the line endings detected by `lineEnd11Len`.
+func appendLines11(out, src []byte) []byte {
+	for len(src) > 0 {
+		size := lineEnd11Len(src)
+		if size > 0 {
+			out = append(out, '\n')
+			src = src[size:]
+			continue
+		}
+		out = append(out, src[0])
+		src = src[1:]
+	}
+	return out
+}
+
+// Length of a comment, CDATA section or processing instruction at the start of
+// the input, or 0.
+func rawSectionLen(src []byte) int {
+	for _, pair := range [...][2]string{
+		{`<!--`, `-->`},
+		{`<![CDATA[`, `]]>`},
+		{`<?`, `?>`},
+	} {
+		if !bytes.HasPrefix(src, []byte(pair[0])) {
+			continue
+		}
+		end := bytes.Index(src[len(pair[0]):], []byte(pair[1]))
+		if end < 0 {
+			return len(src)
+		}
+		return len(pair[0]) + end + len(pair[1])
+	}
+	return 0
+}
+
+// Parses a character reference such as `&#x1;` or `&#1;`, returning its value
+// and length, or 0 length if invalid.
+func parseCharRef(src []byte) (rune, int) {
+	end := bytes.IndexByte(src, ';')
+	if end < 0 {
+		return 0, 0
+	}
+
+	digits := string(src[len(`&#`):end])
+	base := 10
+	if strings.HasPrefix(digits, `x`) {
+		digits = digits[1:]
+		base = 16
+	}
+
+	val, err := strconv.ParseUint(digits, base, 32)
+	if err != nil {
+		return 0, 0
+	}
+	return rune(val), end + 1
+}
+
+// Control characters allowed in XML 1.1 (via references), but not in XML 1.0.
+func isRestrictedChar(char rune) bool {
+	return char >= 0x01 && char <= 0x1F && !isInCharacterRange(char)
+}
+
+/*
+Control characters which XML 1.1 allows only via references, but which
+`encoding/xml` accepts literally. Other restricted characters are already
+rejected by `encoding/xml`.
+*/
+func isRestrictedLiteral(char rune) bool {
+	return char >= 0x7F && char <= 0x9F && char != 0x85
+}
+
+/*
+Reverts the changes made by `prepareVersion11` in the decoded nodes, which are
+modified in-place.
+*/
+func restoreVersion11(nodes Nodes, rewritten bool) {
+	for ind, node := range nodes {
+		if !rewritten {
+			break
+		}
+		pi, ok := node.(Pi)
+		if ok && pi.Target == `xml` {
+			pi.Content = strings.Replace(pi.Content, Version10, Version11, 1)
+			nodes[ind] = pi
+			break
+		}
+	}
+	restorePlaceholders(nodes)
+}
+
+func restorePlaceholders(nodes Nodes) {
+	for ind, node := range nodes {
+		switch node := node.(type) {
+		case Text:
+			nodes[ind] = Text(restorePlaceholderChars(string(node)))
+
+		case Elem:
+			for ind, attr := range node.Attrs {
+				node.Attrs[ind].Value = restorePlaceholderChars(attr.Value)
+			}
+			restorePlaceholders(node.Nodes)
+		}
+	}
+}
+
+func restorePlaceholderChars(val string) string {
+	return strings.Map(func(char rune) rune {
+		if char > placeholderBase && isRestrictedChar(char-placeholderBase) {
+			return char - placeholderBase
+		}
+		return char
+	}, val)
+}
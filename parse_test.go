@@ -0,0 +1,290 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `simple.xml`))
+	require.NoError(t, err)
+	require.Equal(t, expectedSimple, doc)
+}
+
+func TestParseShortcuts(t *testing.T) {
+	src := read(t, `simple.xml`)
+
+	doc, err := Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, expectedSimple, doc)
+
+	doc, err = ParseString(string(src))
+	require.NoError(t, err)
+	require.Equal(t, expectedSimple, doc)
+
+	_, err = ParseString(`<one><two></one>`)
+	require.EqualError(t, err, `decoding <one><two>: XML syntax error on line 1: element <two> closed by </one>`)
+}
+
+func TestParseVersion11(t *testing.T) {
+	src := []byte("<?xml version=\"1.1\"?>\n<one two=\"&#x1;\">three&#x2;four\u0085five\u2028six<!-- &#x3; --></one>")
+
+	expected := Nodes{
+		Pi{Target: `xml`, Content: `version="1.1"`},
+		Text("\n"),
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{{Name: Name{Local: `two`}, Value: "\x01"}},
+			Nodes: Nodes{
+				Text("three\x02four\nfive\nsix"),
+				Comment(` &#x3; `),
+			},
+		},
+	}
+
+	doc, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, expected, doc)
+
+	doc, err = Parser{Version: Version11}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, expected, doc)
+
+	_, err = Parser{Version: Version10}.Parse(src)
+	require.Error(t, err)
+
+	doc, err = Parser{Version: Version11}.Parse([]byte("<one>two&#x80;<!--three\u0085four--><?five six\u2028seven?><![CDATA[eight\r\u0085nine]]></one>"))
+	require.NoError(t, err)
+	require.Equal(t, Nodes{Elem{
+		Name:  Name{Local: `one`},
+		Attrs: []Attr{},
+		Nodes: Nodes{
+			Text("two\u0080"),
+			Comment("three\nfour"),
+			Pi{Target: `five`, Content: "six\nseven"},
+			CData("eight\nnine"),
+		},
+	}}, doc)
+
+	_, err = Parser{Version: Version11}.Parse([]byte("<one>\ntwo\u0080</one>"))
+	require.EqualError(t, err, `XML syntax error on line 2: character U+0080 must be written as a character reference in XML 1.1`)
+
+	_, err = Parser{Version: Version10}.Parse([]byte("<one>two\u0080</one>"))
+	require.NoError(t, err)
+}
+
+func TestParseCData(t *testing.T) {
+	src := []byte(`<one>two<![CDATA[three <four> & ]]>five<![CDATA[]]></one>`)
+
+	expected := Nodes{Elem{
+		Name:  Name{Local: `one`},
+		Attrs: []Attr{},
+		Nodes: Nodes{Text(`two`), CData(`three <four> & `), Text(`five`), CData(``)},
+	}}
+
+	doc, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, expected, doc)
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(out))
+
+	out, err = xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one>twothree &lt;four&gt; &amp; five</one>`, string(out))
+
+	var decoded Nodes
+	require.NoError(t, decoded.Decode(xml.NewDecoder(bytes.NewReader(src))))
+	require.Equal(t, Text(`three <four> & `), decoded[0].(Elem).Nodes[1])
+}
+
+func TestParseMaxTextSize(t *testing.T) {
+	src := []byte(`<one><two>three</two>four</one>`)
+
+	_, err := Parser{Limits: Limits{MaxTextSize: 5}}.Parse(src)
+	require.NoError(t, err)
+
+	_, err = Parser{Limits: Limits{MaxTextSize: 4}}.Parse(src)
+	require.EqualError(t, err, `text node of 5 bytes exceeds MaxTextSize of 4 bytes`)
+}
+
+func TestParseWithTokens(t *testing.T) {
+	doc, tokens, err := Parser{}.ParseWithTokens(read(t, `simple.xml`))
+	require.NoError(t, err)
+	require.Equal(t, expectedSimple, doc)
+	require.Len(t, tokens, 17)
+
+	require.Equal(t, xml.ProcInst{Target: `xml`, Inst: []byte(`version="1.0" encoding="utf-8"`)}, tokens[0])
+	require.Equal(t, xml.CharData("\n"), tokens[1])
+	require.Equal(t, xml.EndElement{Name: xml.Name{Local: `one`}}, tokens[16])
+
+	var nodes, ends int
+	walkPath(doc, nil, func(Path, Node) { nodes++ })
+	for _, tok := range tokens {
+		if _, ok := tok.(xml.EndElement); ok {
+			ends++
+		}
+	}
+	require.Equal(t, len(tokens), nodes+ends, `every node corresponds to a token, plus end tokens`)
+
+	_, tokens, err = Parser{}.ParseWithTokens([]byte(`<one><two></one>`))
+	require.Error(t, err)
+	require.Len(t, tokens, 2)
+}
+
+func TestParseInternAttrValues(t *testing.T) {
+	src := []byte(`<list><item kind="one" flag="true"/><item kind="one" flag="false"/><item kind="two" flag="true"/></list>`)
+
+	doc, err := Parser{InternAttrValues: true}.Parse(src)
+	require.NoError(t, err)
+
+	expected, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, expected, doc)
+
+	items := doc[0].(Elem).Nodes
+	first := items[0].(Elem).Attrs[0].Value
+	second := items[1].(Elem).Attrs[0].Value
+	require.Equal(t, first, second)
+	require.Equal(t, stringData(first), stringData(second), `must share memory`)
+	require.Equal(t, stringData(items[0].(Elem).Attrs[1].Value), stringData(items[2].(Elem).Attrs[1].Value))
+
+	out, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<list><item kind="one" flag="true"></item><item kind="one" flag="false"></item><item kind="two" flag="true"></item></list>`, string(out))
+}
+
+func BenchmarkParseRetained(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString(`<records>`)
+	for ind := 0; ind < 10000; ind++ {
+		fmt.Fprintf(&buf, `<record id="%d" status="active" type="https://example.com/types/record" enabled="true"/>`, ind)
+	}
+	buf.WriteString(`</records>`)
+	src := buf.Bytes()
+
+	for _, parser := range []Parser{{}, {InternAttrValues: true}} {
+		parser := parser
+		b.Run(fmt.Sprintf(`intern=%v`, parser.InternAttrValues), func(b *testing.B) {
+			var retained uint64
+			for ind := 0; ind < b.N; ind++ {
+				before := heapAlloc()
+				doc, err := parser.Parse(src)
+				if err != nil {
+					b.Fatal(err)
+				}
+				retained += heapAlloc() - before
+				runtime.KeepAlive(doc)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), `retained-B/op`)
+		})
+	}
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func stringData(val string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&val)).Data
+}
+
+func TestParseLenient(t *testing.T) {
+	src := []byte(`<p class=intro>one&nbsp;two &copy; 2021 &unknown; AT&T<br></p>`)
+
+	_, err := Parser{}.Parse(src)
+	require.Error(t, err)
+
+	doc, err := Parser{Lenient: true}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, Nodes{Elem{
+		Name:  Name{Local: `p`},
+		Attrs: []Attr{{Name: Name{Local: `class`}, Value: `intro`}},
+		Nodes: Nodes{
+			Text("one\u00a0two \u00a9 2021 &unknown; AT&T"),
+			Elem{Name: Name{Local: `br`}, Attrs: []Attr{}},
+		},
+	}}, doc)
+}
+
+func TestParseCharsetReader(t *testing.T) {
+	src := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><one>\xa9 2021<![CDATA[\xe9]]></one>")
+
+	latin1 := func(label string, input io.Reader) (io.Reader, error) {
+		if label != `ISO-8859-1` {
+			return nil, fmt.Errorf(`unsupported charset %q`, label)
+		}
+		src, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		var buf strings.Builder
+		for _, char := range src {
+			buf.WriteRune(rune(char))
+		}
+		return strings.NewReader(buf.String()), nil
+	}
+
+	doc, err := Parser{CharsetReader: latin1}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, Nodes{
+		Pi{Target: `xml`, Content: `version="1.0" encoding="ISO-8859-1"`},
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{},
+			Nodes: Nodes{Text("\u00a9 2021"), CData("\u00e9")},
+		},
+	}, doc)
+
+	_, err = Parser{}.Parse(src)
+	require.Error(t, err)
+
+	_, err = Parser{CharsetReader: latin1}.Parse([]byte(`<?xml version="1.0" encoding="KOI8-R"?><one/>`))
+	require.EqualError(t, err, `unsupported charset "KOI8-R"`)
+}
+
+func TestParseRawAttrs(t *testing.T) {
+	src := []byte("<one a = 'x&#9;y' b=\"&quot;q&quot;\"\r\n c=\"one\r\ntwo\"><two/></one>")
+
+	doc, err := Parser{RawAttrs: true}.Parse(src)
+	require.NoError(t, err)
+
+	elem := doc[0].(Elem)
+	require.Equal(t, []Attr{
+		{Name: Name{Local: `a`}, Value: "x\ty"},
+		{Name: Name{Local: `b`}, Value: `"q"`},
+		{Name: Name{Local: `c`}, Value: "one\ntwo"},
+	}, elem.Attrs)
+	require.Equal(t, []string{`x&#9;y`, `&quot;q&quot;`, "one\r\ntwo"}, elem.RawAttrs)
+	require.Nil(t, elem.Nodes[0].(Elem).RawAttrs)
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, "<one a=\"x&#9;y\" b=\"&quot;q&quot;\" c=\"one\r\ntwo\"><two></two></one>", string(out))
+
+	elem.Attrs[0].Value = `z`
+	out, err = MarshalOptions{SortAttrs: true}.Marshal(Nodes{elem})
+	require.NoError(t, err)
+	require.Equal(t, "<one a=\"z\" b=\"&quot;q&quot;\" c=\"one\r\ntwo\"><two></two></one>", string(out))
+
+	doc, err = Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Nil(t, doc[0].(Elem).RawAttrs)
+
+	doc, err = Parser{RawAttrs: true, Lenient: true}.Parse([]byte(`<p class=intro id="one"></p>`))
+	require.NoError(t, err)
+	require.Nil(t, doc[0].(Elem).RawAttrs)
+}
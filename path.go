@@ -0,0 +1,64 @@
+package xt
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+Location of a node in a tree, as a chain of indexes. The first index refers to
+the top-level `Nodes`, and each subsequent index refers to the `Nodes` of the
+element at the previous location, or to the nested `Nodes` at the previous
+location, where supported. Formatted like a JSON path:
+
+	Path{2, 0}.String() == "nodes[2].nodes[0]"
+*/
+type Path []int
+
+func (self Path) String() string {
+	var buf strings.Builder
+	for ind, val := range self {
+		if ind > 0 {
+			buf.WriteByte('.')
+		}
+		buf.WriteString(`nodes[`)
+		buf.WriteString(strconv.Itoa(val))
+		buf.WriteByte(']')
+	}
+	return buf.String()
+}
+
+/*
+Traverses the nodes depth-first in document order, calling `fn` with each node
+and its path. The path is reused between calls; `fn` must copy it to retain it.
+*/
+func walkPath(nodes Nodes, path Path, fn func(Path, Node)) {
+	for ind, node := range nodes {
+		path := append(path, ind)
+		fn(path, node)
+
+		elem, ok := nodeElem(node)
+		if ok {
+			walkPath(elem.Nodes, path, fn)
+		}
+	}
+}
+
+func (self Path) clone() Path {
+	return append(Path(nil), self...)
+}
+
+/*
+Returns the element stored in the node, if any. For `*Elem`, this returns the
+same pointer. For `Elem`, this returns a pointer to a copy, which shares the
+`Attrs` and `Nodes` slices with the original.
+*/
+func nodeElem(node Node) (*Elem, bool) {
+	switch node := node.(type) {
+	case Elem:
+		return &node, true
+	case *Elem:
+		return node, node != nil
+	}
+	return nil, false
+}
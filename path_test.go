@@ -0,0 +1,12 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathString(t *testing.T) {
+	require.Equal(t, ``, Path(nil).String())
+	require.Equal(t, `nodes[2].nodes[0]`, Path{2, 0}.String())
+}
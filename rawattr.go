@@ -0,0 +1,146 @@
+package xt
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+Finds the attribute values in the source of a start tag, such as
+`<one two="three">`, returning the text between the quotes. Returns nil if the
+tag can't be scanned, or doesn't contain exactly the expected number of
+attributes.
+*/
+func scanRawAttrs(src []byte, count int) []string {
+	if count == 0 || len(src) == 0 || src[0] != '<' {
+		return nil
+	}
+
+	rest := string(src[1:])
+	ind := strings.IndexAny(rest, whitespace+`/>`)
+	if ind < 0 {
+		return nil
+	}
+	rest = rest[ind:]
+
+	out := make([]string, 0, count)
+	for {
+		rest = strings.TrimLeft(rest, whitespace)
+		if rest == "" {
+			return nil
+		}
+		if rest[0] == '>' || rest[0] == '/' {
+			break
+		}
+
+		ind := strings.IndexAny(rest, whitespace+`=`)
+		if ind <= 0 {
+			return nil
+		}
+		rest = strings.TrimLeft(rest[ind:], whitespace)
+		if !strings.HasPrefix(rest, `=`) {
+			return nil
+		}
+
+		value, next, ok := cutDoctypeLiteral(strings.TrimLeft(rest[1:], whitespace))
+		if !ok {
+			return nil
+		}
+		out = append(out, value)
+		rest = next
+	}
+
+	if len(out) != count {
+		return nil
+	}
+	return out
+}
+
+/*
+Returns the raw source of the attribute, if recorded in `Elem.RawAttrs` and
+still equivalent to its value. Used by `MarshalOptions` to reproduce the
+original text.
+*/
+func rawAttrLookup(elem Elem) func(Attr) string {
+	if len(elem.RawAttrs) != len(elem.Attrs) {
+		return func(Attr) string { return "" }
+	}
+	return func(attr Attr) string {
+		for ind, other := range elem.Attrs {
+			if other != attr {
+				continue
+			}
+			raw := elem.RawAttrs[ind]
+			if strings.ContainsAny(raw, `"<`) {
+				continue
+			}
+			val, ok := unescapeAttr(raw)
+			if ok && val == attr.Value {
+				return raw
+			}
+		}
+		return ""
+	}
+}
+
+/*
+Decodes an attribute value like `encoding/xml` in strict mode, supporting the
+predefined entities and character references, and normalizing line endings.
+Returns false if the input contains a malformed or unknown reference.
+*/
+func unescapeAttr(src string) (string, bool) {
+	if !strings.ContainsAny(src, "&\r") {
+		return src, true
+	}
+
+	var buf strings.Builder
+	for ind := 0; ind < len(src); ind++ {
+		char := src[ind]
+
+		if char == '\r' {
+			buf.WriteByte('\n')
+			if ind+1 < len(src) && src[ind+1] == '\n' {
+				ind++
+			}
+			continue
+		}
+		if char != '&' {
+			buf.WriteByte(char)
+			continue
+		}
+
+		end := strings.IndexByte(src[ind:], ';')
+		if end < 0 {
+			return "", false
+		}
+		name := src[ind+1 : ind+end]
+		ind += end
+
+		switch name {
+		case `lt`:
+			buf.WriteByte('<')
+		case `gt`:
+			buf.WriteByte('>')
+		case `amp`:
+			buf.WriteByte('&')
+		case `apos`:
+			buf.WriteByte('\'')
+		case `quot`:
+			buf.WriteByte('"')
+		default:
+			if !strings.HasPrefix(name, `#`) {
+				return "", false
+			}
+			num, base := name[1:], 10
+			if strings.HasPrefix(num, `x`) {
+				num, base = num[1:], 16
+			}
+			code, err := strconv.ParseUint(num, base, 32)
+			if err != nil {
+				return "", false
+			}
+			buf.WriteRune(rune(code))
+		}
+	}
+	return buf.String(), true
+}
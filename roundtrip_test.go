@@ -0,0 +1,38 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmptyRoundTrip(t *testing.T) {
+	testRoundTrip(t, `empty.xml`, nil)
+}
+
+func TestWhitespaceRoundTrip(t *testing.T) {
+	testRoundTrip(t, `whitespace.xml`, Nodes{Text("  \n\n    \n")})
+}
+
+func testRoundTrip(t *testing.T, path string, expected Nodes) {
+	t.Helper()
+	src := read(t, path)
+
+	var doc Nodes
+	require.NoError(t, doc.Decode(xml.NewDecoder(bytes.NewReader(src))))
+	require.Equal(t, expected, doc)
+
+	out, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(out))
+
+	doc, err = Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), len(doc))
+
+	out, err = MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(out))
+}
@@ -0,0 +1,73 @@
+package xt
+
+/*
+Minimal description of an element structure, used by `Sample` to generate
+example documents.
+*/
+type Schema struct {
+	Name     Name
+	Attrs    []Name
+	Children []Schema
+
+	/**
+	Minimum and maximum number of occurrences of this element within its
+	parent. Zero `Max` means unbounded. Ignored for the root element.
+	*/
+	Min int
+	Max int
+}
+
+/*
+Generates an example document matching the schema, with placeholder content.
+The root element occurs once. Every child element occurs `Min` times, but at
+least once, so that optional elements are represented in the sample. Attribute
+values are placeholders equal to the attribute's local name, and elements
+without children contain placeholder text equal to the element's local name:
+
+	Schema{
+		Name:     Name{Local: "list"},
+		Children: []Schema{{Name: Name{Local: "item"}, Attrs: []Name{{Local: "id"}}, Min: 2}},
+	}
+	->
+	<list><item id="id">item</item><item id="id">item</item></list>
+
+The output has no formatting whitespace; use `MarshalOptions` to pretty-print
+it.
+*/
+func Sample(schema Schema) Nodes {
+	return Nodes{schema.sample()}
+}
+
+func (self Schema) sample() Elem {
+	out := Elem{Name: self.Name}
+
+	if self.Attrs != nil {
+		out.Attrs = make([]Attr, 0, len(self.Attrs))
+		for _, name := range self.Attrs {
+			out.Attrs = append(out.Attrs, Attr{Name: name, Value: name.Local})
+		}
+	}
+
+	if len(self.Children) == 0 {
+		out.Nodes = Nodes{Text(self.Name.Local)}
+		return out
+	}
+
+	for _, child := range self.Children {
+		for ind := 0; ind < child.occurrences(); ind++ {
+			out.Nodes = append(out.Nodes, child.sample())
+		}
+	}
+	return out
+}
+
+func (self Schema) occurrences() int {
+	out := self.Min
+	if out < 1 {
+		out = 1
+	}
+	if self.Max > 0 && out > self.Max {
+		out = self.Max
+	}
+	return out
+}
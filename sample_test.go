@@ -0,0 +1,35 @@
+package xt
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample(t *testing.T) {
+	schema := Schema{
+		Name:  Name{Local: `catalog`},
+		Attrs: []Name{{Local: `version`}},
+		Children: []Schema{
+			{Name: Name{Local: `title`}, Min: 1, Max: 1},
+			{
+				Name:  Name{Local: `book`},
+				Attrs: []Name{{Local: `id`}, {Space: NamespaceXML, Local: `lang`}},
+				Min:   2,
+				Children: []Schema{
+					{Name: Name{Local: `author`}, Min: 1, Max: 3},
+					{Name: Name{Local: `note`}, Min: 0, Max: 1},
+				},
+			},
+		},
+	}
+
+	doc := Sample(schema)
+
+	out, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<catalog version="version"><title>title</title><book id="id" xml:lang="lang"><author>author</author><note>note</note></book><book id="id" xml:lang="lang"><author>author</author><note>note</note></book></catalog>`, string(out))
+
+	require.Len(t, doc.ToRecords(Name{Local: `book`}, nil), 2)
+}
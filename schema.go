@@ -0,0 +1,122 @@
+package xt
+
+import "encoding/json"
+
+/*
+Returns a JSON Schema (draft-07) describing the JSON representation of `Nodes`,
+as produced by `Nodes.MarshalJSON` and accepted by `(*Nodes).UnmarshalJSON`.
+Allows consumers in other languages to validate this representation. The root
+schema describes an array of nodes; the schema of a single node is available
+as "#/definitions/node". The schema is generated on every call, and the caller
+may modify the result.
+
+The alternative representation `CompactJSON` is not described.
+*/
+func JSONSchema() []byte {
+	out, err := json.MarshalIndent(jsonSchema(), ``, `  `)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+type jsonObject = map[string]interface{}
+
+func jsonSchema() jsonObject {
+	return jsonObject{
+		`$schema`:     `http://json-schema.org/draft-07/schema#`,
+		`$id`:         `https://github.com/purelabio/xt/nodes.schema.json`,
+		`title`:       `XML nodes`,
+		`$ref`:        `#/definitions/nodes`,
+		`definitions`: jsonSchemaDefinitions(),
+	}
+}
+
+func jsonSchemaDefinitions() jsonObject {
+	str := jsonObject{`type`: `string`}
+
+	return jsonObject{
+		`nodes`: jsonObject{
+			`type`:  []string{`array`, `null`},
+			`items`: jsonObject{`$ref`: `#/definitions/node`},
+		},
+
+		`node`: jsonObject{
+			`oneOf`: []jsonObject{
+				{`$ref`: `#/definitions/pi`},
+				{`$ref`: `#/definitions/decl`},
+				{`$ref`: `#/definitions/comment`},
+				{`$ref`: `#/definitions/text`},
+				{`$ref`: `#/definitions/cdata`},
+				{`$ref`: `#/definitions/entity`},
+				{`$ref`: `#/definitions/doctype`},
+				{`$ref`: `#/definitions/elem`},
+			},
+		},
+
+		`name`: jsonSchemaObject(nil, jsonObject{`space`: str, `local`: str}),
+
+		`attr`: jsonSchemaObject(nil, jsonObject{
+			`name`:  jsonObject{`$ref`: `#/definitions/name`},
+			`value`: str,
+		}),
+
+		`pi`: jsonSchemaObject([]string{`type`}, jsonObject{
+			`type`:    jsonObject{`const`: TypePi},
+			`target`:  str,
+			`content`: str,
+		}),
+
+		`decl`:    jsonSchemaContentNode(TypeDecl),
+		`comment`: jsonSchemaContentNode(TypeComment),
+		`text`:    jsonSchemaContentNode(TypeText),
+		`cdata`:   jsonSchemaContentNode(TypeCData),
+
+		`entity`: jsonSchemaObject([]string{`type`}, jsonObject{
+			`type`: jsonObject{`const`: TypeEntity},
+			`name`: str,
+		}),
+
+		`doctype`: jsonSchemaObject([]string{`type`}, jsonObject{
+			`type`:           jsonObject{`const`: TypeDoctype},
+			`name`:           str,
+			`publicId`:       str,
+			`systemId`:       str,
+			`internalSubset`: str,
+		}),
+
+		`elem`: jsonSchemaObject([]string{`type`}, jsonObject{
+			`type`:   jsonObject{`const`: TypeElem},
+			`name`:   jsonObject{`$ref`: `#/definitions/name`},
+			`prefix`: str,
+			`attrs`: jsonObject{
+				`type`:  []string{`array`, `null`},
+				`items`: jsonObject{`$ref`: `#/definitions/attr`},
+			},
+			`rawAttrs`: jsonObject{
+				`type`:  []string{`array`, `null`},
+				`items`: str,
+			},
+			`nodes`: jsonObject{`$ref`: `#/definitions/nodes`},
+		}),
+	}
+}
+
+func jsonSchemaContentNode(typ string) jsonObject {
+	return jsonSchemaObject([]string{`type`}, jsonObject{
+		`type`:    jsonObject{`const`: typ},
+		`content`: jsonObject{`type`: `string`},
+	})
+}
+
+func jsonSchemaObject(required []string, props jsonObject) jsonObject {
+	out := jsonObject{
+		`type`:                 `object`,
+		`properties`:           props,
+		`additionalProperties`: false,
+	}
+	if required != nil {
+		out[`required`] = required
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package xt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(JSONSchema()))
+	require.NoError(t, err)
+
+	test := func(src []byte, valid bool) {
+		t.Helper()
+
+		res, err := schema.Validate(gojsonschema.NewBytesLoader(src))
+		require.NoError(t, err)
+		require.Equal(t, valid, res.Valid(), `%v`, res.Errors())
+	}
+
+	test(read(t, `simple.json`), true)
+
+	for _, doc := range []Nodes{expectedNsAliased, expectedNsInlined, {Decl(`one`)}, {CData(`one`)}, {EntityRef(`one`)}, {Doctype{Name: `one`, InternalSubset: `two`}}, nil} {
+		src, err := json.Marshal(doc)
+		require.NoError(t, err)
+		test(src, true)
+	}
+
+	test([]byte(`{"type": "text"}`), false)
+	test([]byte(`[{"content": "one"}]`), false)
+	test([]byte(`[{"type": "one"}]`), false)
+	test([]byte(`[{"type": "text", "content": 1}]`), false)
+	test([]byte(`[{"type": "elem", "name": {"local": "one"}, "extra": true}]`), false)
+	test([]byte(`[{"type": "elem", "nodes": [{"type": "comment"}], "attrs": [{"value": 1}]}]`), false)
+}
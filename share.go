@@ -0,0 +1,67 @@
+package xt
+
+/*
+Pool of decoded elements, used by `Parser.ShareSubtrees`. Each element is
+identified by a canonical key, which consists of its name, attributes and
+content, where child elements are represented by their pool indexes. Since
+child elements are pooled before their parents, identical subtrees produce
+identical keys, and the size of each key is proportional to the element's own
+content rather than to the entire subtree.
+*/
+type subtreePool struct {
+	ids   map[string]int
+	elems []Elem
+}
+
+/*
+Returns the first pooled element with the same key, or adds the given element
+to the pool. Also returns the element's index in the pool.
+*/
+func (self *subtreePool) share(elem Elem, key []byte) (Elem, int) {
+	id, ok := self.ids[string(key)]
+	if ok {
+		return self.elems[id], id
+	}
+
+	id = len(self.elems)
+	self.ids[string(key)] = id
+	self.elems = append(self.elems, elem)
+	return elem, id
+}
+
+/*
+Starts the key of an element with its name and attributes, including their
+raw text, if recorded via `Parser.RawAttrs`.
+*/
+func appendSubtreeHead(key []byte, elem Elem) []byte {
+	key = appendBinaryString(key, elem.Name.Space)
+	key = appendBinaryString(key, elem.Name.Local)
+	key = appendBinaryLen(key, len(elem.Attrs), elem.Attrs == nil)
+	for _, attr := range elem.Attrs {
+		key = appendBinaryString(key, attr.Name.Space)
+		key = appendBinaryString(key, attr.Name.Local)
+		key = appendBinaryString(key, attr.Value)
+	}
+	key = appendBinaryLen(key, len(elem.RawAttrs), elem.RawAttrs == nil)
+	for _, raw := range elem.RawAttrs {
+		key = appendBinaryString(key, raw)
+	}
+	return key
+}
+
+/*
+Appends a child node to the key of its parent. Child elements are represented
+by their pool indexes, other nodes by their binary encoding.
+*/
+func appendSubtreeNode(key []byte, node Node, id int) []byte {
+	_, ok := node.(Elem)
+	if ok {
+		return appendUvarint(append(key, binaryElem), uint64(id))
+	}
+	out, err := appendBinaryNode(key, node)
+	if err != nil {
+		// Unreachable: the decoder only produces supported node types.
+		panic(err)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package xt
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShareSubtrees(t *testing.T) {
+	src := []byte(`<list><item kind="one"><two>three</two><!--four--></item><item kind="one"><two>three</two><!--four--></item><item kind="two"><two>three</two></item></list>`)
+
+	doc, err := Parser{ShareSubtrees: true}.Parse(src)
+	require.NoError(t, err)
+
+	expected, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+	require.Equal(t, expected, doc)
+
+	items := doc[0].(Elem).Nodes
+	first := items[0].(Elem)
+	second := items[1].(Elem)
+	third := items[2].(Elem)
+
+	require.Equal(t, first, second)
+	require.True(t, &first.Nodes[0] == &second.Nodes[0], `must share memory`)
+	require.True(t, &first.Attrs[0] == &second.Attrs[0], `must share memory`)
+	require.False(t, &first.Attrs[0] == &third.Attrs[0])
+	require.True(t, &first.Nodes[0].(Elem).Nodes[0] == &third.Nodes[0].(Elem).Nodes[0], `nested subtrees must share memory`)
+
+	copied := doc.Clone()
+	copied[0].(Elem).Nodes[0].(Elem).Attrs[0].Value = `five`
+	copied[0].(Elem).Nodes[0].(Elem).Nodes[0].(Elem).Nodes[0] = Text(`six`)
+	require.Equal(t, expected, doc)
+	require.Equal(t, `one`, second.Attrs[0].Value)
+}
+
+func BenchmarkParseShareSubtrees(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString(`<records>`)
+	for ind := 0; ind < 10000; ind++ {
+		fmt.Fprintf(&buf, `<record><status>active</status><type>https://example.com/types/record</type><flags><flag>%d</flag></flags></record>`, ind%10)
+	}
+	buf.WriteString(`</records>`)
+	src := buf.Bytes()
+
+	for _, parser := range []Parser{{}, {ShareSubtrees: true}} {
+		parser := parser
+		b.Run(fmt.Sprintf(`share=%v`, parser.ShareSubtrees), func(b *testing.B) {
+			var retained uint64
+			for ind := 0; ind < b.N; ind++ {
+				before := heapAlloc()
+				doc, err := parser.Parse(src)
+				if err != nil {
+					b.Fatal(err)
+				}
+				retained += heapAlloc() - before
+				runtime.KeepAlive(doc)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), `retained-B/op`)
+		})
+	}
+}
+
+func TestParseShareSubtreesRawAttrs(t *testing.T) {
+	src := []byte("<r><a x=\"&#9;\"/><a x=\"\t\"/><a x=\"&#9;\"/></r>")
+
+	doc, err := Parser{RawAttrs: true, ShareSubtrees: true}.Parse(src)
+	require.NoError(t, err)
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, "<r><a x=\"&#9;\"></a><a x=\"\t\"></a><a x=\"&#9;\"></a></r>", string(out))
+
+	nodes := doc[0].(Elem).Nodes
+	require.Equal(t, nodes[0], nodes[2])
+	require.Equal(t, []string{"\t"}, nodes[1].(Elem).RawAttrs)
+}
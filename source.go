@@ -0,0 +1,65 @@
+package xt
+
+import (
+	"bytes"
+	"strings"
+)
+
+/*
+Encodes the nodes, reproducing the formatting conventions of a reference
+document, such as the original file the nodes were decoded from. Infers the
+indentation unit, the line ending and the presence of a BOM and a final
+newline, and pretty-prints accordingly via `MarshalOptions`. Useful for tools
+which edit a document and write it back, minimizing the diff against the
+original. Mixed content is written as-is; see `MarshalOptions.Indent`.
+
+The indentation unit is the shortest indentation of any line starting with a
+tag, either a tab or a run of spaces. When the reference has no indented lines,
+the output is not pretty-printed.
+*/
+func MarshalLikeSource(nodes Nodes, reference []byte) ([]byte, error) {
+	opts := inferFormat(reference)
+
+	out, err := opts.Marshal(nodes)
+	if err != nil {
+		return out, err
+	}
+
+	if len(out) > 0 && bytes.HasSuffix(reference, []byte("\n")) {
+		out = append(out, opts.newline()...)
+	}
+	return out, nil
+}
+
+func inferFormat(reference []byte) (out MarshalOptions) {
+	out.EmitBOM = bytes.HasPrefix(reference, utf8Bom)
+	if bytes.Contains(reference, []byte("\r\n")) {
+		out.Newline = "\r\n"
+	}
+
+	for _, line := range strings.Split(string(reference), "\n") {
+		content := strings.TrimLeft(line, " \t")
+		indent := line[:len(line)-len(content)]
+		if indent == "" || !strings.HasPrefix(content, `<`) {
+			continue
+		}
+
+		if indent[0] == '\t' {
+			out.Indent = "\t"
+			break
+		}
+
+		indent = indent[:len(indent)-len(strings.TrimLeft(indent, ` `))]
+		if out.Indent == "" || len(indent) < len(out.Indent) {
+			out.Indent = indent
+		}
+	}
+	return
+}
+
+func (self MarshalOptions) newline() string {
+	if self.Newline != "" {
+		return self.Newline
+	}
+	return "\n"
+}
@@ -0,0 +1,53 @@
+package xt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalLikeSource(t *testing.T) {
+	src := read(t, `config.xml`)
+
+	doc, err := Parser{}.Parse(src)
+	require.NoError(t, err)
+
+	out, err := MarshalLikeSource(doc, src)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(out))
+
+	config := doc[2].(Elem)
+	config.Nodes = append(config.Nodes, Elem{
+		Name:  Name{Local: `cache`},
+		Nodes: Nodes{Elem{Name: Name{Local: `size`}, Nodes: Nodes{Text(`64`)}}},
+	})
+	doc[2] = config
+
+	out, err = MarshalLikeSource(doc, src)
+	require.NoError(t, err)
+	require.Equal(t, strings.Replace(string(src), "</config>", "  <cache>\n    <size>64</size>\n  </cache>\n</config>", 1), string(out))
+}
+
+func TestMarshalLikeSourceTabsCRLF(t *testing.T) {
+	src := "<one>\r\n\t<two>\r\n\t\t<three></three>\r\n\t</two>\r\n</one>\r\n"
+
+	doc, err := Parser{}.Parse([]byte(src))
+	require.NoError(t, err)
+
+	out, err := MarshalLikeSource(doc, []byte(src))
+	require.NoError(t, err)
+	require.Equal(t, src, string(out))
+
+	out, err = MarshalLikeSource(doc, []byte("\xef\xbb\xbf"+src))
+	require.NoError(t, err)
+	require.Equal(t, "\xef\xbb\xbf"+src, string(out))
+}
+
+func TestMarshalLikeSourceCompact(t *testing.T) {
+	src := []byte(`<one><two></two></one>`)
+
+	out, err := MarshalLikeSource(Nodes{Elem{Name: Name{Local: `one`}, Nodes: Nodes{Text("\n  "), Elem{Name: Name{Local: `two`}}}}}, src)
+	require.NoError(t, err)
+	require.Equal(t, "<one>\n  <two></two></one>", string(out))
+}
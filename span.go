@@ -0,0 +1,52 @@
+package xt
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+/*
+Byte range of a node in the decoder's input, as returned by `DecodeWithPos`.
+`Start` is inclusive and `End` is exclusive, so for a decoder reading `src`,
+the source of the node is `src[Start:End]`.
+*/
+type Span struct {
+	Start int
+	End   int
+}
+
+/*
+Decodes all remaining nodes from the decoder, like `(*Nodes).Decode`, and
+additionally returns the byte range of each top-level node, in the same
+order, as reported by `(*xml.Decoder).InputOffset`. For an element, the range
+covers the start tag, the content and the end tag. Useful for mapping nodes
+back to their location in the original file, such as for highlighting
+errors. Offsets are relative to the decoder's input, after conversion via
+`CharsetReader`, if any.
+
+On error, returns the nodes and spans decoded so far.
+*/
+func DecodeWithPos(dec *xml.Decoder) (Nodes, []Span, error) {
+	var nodes Nodes
+	var spans []Span
+
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nodes, spans, nil
+		}
+		if err != nil {
+			return nodes, spans, err
+		}
+
+		var node Node
+		err = DecodeToken(dec, tok, &node)
+		if err != nil {
+			return nodes, spans, err
+		}
+		nodes = append(nodes, node)
+		spans = append(spans, Span{int(start), int(dec.InputOffset())})
+	}
+}
@@ -0,0 +1,32 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWithPos(t *testing.T) {
+	src := "<?xml version=\"1.0\"?>\n<one two=\"three\"><four/>five</one><!--six-->"
+
+	nodes, spans, err := DecodeWithPos(xml.NewDecoder(bytes.NewReader([]byte(src))))
+	require.NoError(t, err)
+	require.Len(t, nodes, 4)
+	require.Equal(t, []Span{{0, 21}, {21, 22}, {22, 56}, {56, 66}}, spans)
+
+	require.Equal(t, `<?xml version="1.0"?>`, src[spans[0].Start:spans[0].End])
+	require.Equal(t, "\n", src[spans[1].Start:spans[1].End])
+	require.Equal(t, `<one two="three"><four/>five</one>`, src[spans[2].Start:spans[2].End])
+	require.Equal(t, `<!--six-->`, src[spans[3].Start:spans[3].End])
+
+	var expected Nodes
+	require.NoError(t, expected.Decode(xml.NewDecoder(bytes.NewReader([]byte(src)))))
+	require.Equal(t, expected, nodes)
+
+	nodes, spans, err = DecodeWithPos(xml.NewDecoder(bytes.NewReader([]byte(`<one/><two>`))))
+	require.EqualError(t, err, `decoding <two>: XML syntax error on line 1: unexpected EOF`)
+	require.Equal(t, Nodes{Elem{Name: Name{Local: `one`}, Attrs: []Attr{}}}, nodes)
+	require.Equal(t, []Span{{0, 6}}, spans)
+}
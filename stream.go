@@ -0,0 +1,91 @@
+package xt
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+/*
+Extracts "records" from an arbitrary XML document and writes them as
+newline-delimited JSON (JSONL). Every element for which `match` returns true is
+decoded into an `Elem` and written as one JSON line, using the same JSON
+representation as `Elem.MarshalJSON`. Everything else, including the content
+between records, is discarded.
+
+Memory usage is bounded by the largest matched element, rather than by the
+entire document. Matched elements are not searched for nested matches.
+*/
+func StreamRecordsAsJSON(src io.Reader, match func(xml.StartElement) bool, out io.Writer) error {
+	dec := xml.NewDecoder(src)
+	enc := json.NewEncoder(out)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || !match(start) {
+			continue
+		}
+
+		var elem Elem
+		err = dec.DecodeElement(&elem, &start)
+		if err != nil {
+			return err
+		}
+
+		err = enc.Encode(elem)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+/*
+Decodes the remaining top-level nodes one at a time, like `(*Nodes).Decode`,
+passing each node to `fn` instead of accumulating them. Elements are fully
+decoded, including their descendants, before being passed. Memory usage is
+bounded by the largest top-level node, as long as `fn` doesn't retain the
+nodes. Stops at the first error returned by `fn` or by the decoder, and returns
+that error.
+
+For a document with a single root element, this decodes the entire root at
+once. To process the children of the root incrementally, read the root's
+`xml.StartElement` via `(*xml.Decoder).Token` before calling this. Iteration
+stops without error at an end tag which closes an element opened before the
+call, such as the root's end tag.
+*/
+func Iterate(dec *xml.Decoder, fn func(Node) error) error {
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, ok := tok.(xml.EndElement)
+		if ok {
+			return nil
+		}
+
+		var node Node
+		err = DecodeToken(dec, tok, &node)
+		if err != nil {
+			return err
+		}
+
+		err = fn(node)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamRecordsAsJSON(t *testing.T) {
+	src := read(t, `records.xml`)
+
+	isRecord := func(start xml.StartElement) bool {
+		return start.Name.Local == `record`
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, StreamRecordsAsJSON(bytes.NewReader(src), isRecord, &out))
+
+	expected := read(t, `records.jsonl`)
+	require.Equal(t, string(expected), out.String())
+}
+
+func TestIterate(t *testing.T) {
+	src := read(t, `simple.xml`)
+
+	var out Nodes
+	require.NoError(t, Iterate(xml.NewDecoder(bytes.NewReader(src)), func(node Node) error {
+		out = append(out, node)
+		return nil
+	}))
+	require.Equal(t, expectedSimple, out)
+
+	dec := xml.NewDecoder(bytes.NewReader(src))
+	for {
+		tok, err := dec.Token()
+		require.NoError(t, err)
+		if _, ok := tok.(xml.StartElement); ok {
+			break
+		}
+	}
+
+	out = nil
+	require.NoError(t, Iterate(dec, func(node Node) error {
+		out = append(out, node)
+		return nil
+	}))
+	require.Equal(t, expectedSimple[2].(Elem).Nodes, out)
+
+	stop := errors.New(`stop`)
+	count := 0
+	require.Equal(t, stop, Iterate(xml.NewDecoder(bytes.NewReader(src)), func(Node) error {
+		count++
+		if count == 2 {
+			return stop
+		}
+		return nil
+	}))
+	require.Equal(t, 2, count)
+
+	require.Error(t, Iterate(xml.NewDecoder(bytes.NewReader([]byte(`<one><two></one>`))), func(Node) error { return nil }))
+}
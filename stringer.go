@@ -0,0 +1,121 @@
+package xt
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Implements `fmt.Stringer`, producing a compact XML-like representation of the
+nodes for logging and test diagnostics, such as `<one two="three">four</one>`.
+The output is readable but not necessarily valid XML: names are written in
+Clark notation such as `{space}local` unless the element has a prefix,
+namespace declarations are not added, and malformed nodes, such as elements
+with empty names, are written as-is rather than rejected. Never fails. For
+encoding, use `xml.Marshal` or `MarshalOptions`.
+
+The other node types implement `fmt.Stringer` the same way.
+*/
+func (self Nodes) String() string { return nodeString(self) }
+
+func (self Pi) String() string        { return nodeString(self) }
+func (self Decl) String() string      { return nodeString(self) }
+func (self Comment) String() string   { return nodeString(self) }
+func (self Text) String() string      { return nodeString(self) }
+func (self CData) String() string     { return nodeString(self) }
+func (self EntityRef) String() string { return nodeString(self) }
+func (self Doctype) String() string   { return nodeString(self) }
+func (self Elem) String() string      { return nodeString(self) }
+
+func nodeString(node Node) string {
+	var buf strings.Builder
+	writeNodeString(&buf, node)
+	return buf.String()
+}
+
+var (
+	textStringReplacer = strings.NewReplacer(`&`, `&amp;`, `<`, `&lt;`, `>`, `&gt;`)
+	attrStringReplacer = strings.NewReplacer(`&`, `&amp;`, `<`, `&lt;`, `"`, `&quot;`)
+)
+
+func writeNodeString(buf *strings.Builder, node Node) {
+	switch node := node.(type) {
+	case nil:
+	case Pi:
+		buf.WriteString(`<?`)
+		buf.WriteString(node.Target)
+		if node.Content != "" {
+			buf.WriteString(` `)
+			buf.WriteString(node.Content)
+		}
+		buf.WriteString(`?>`)
+	case Decl:
+		buf.WriteString(`<!`)
+		buf.WriteString(string(node))
+		buf.WriteString(`>`)
+	case Doctype:
+		buf.WriteString(`<!`)
+		buf.WriteString(string(node.Decl()))
+		buf.WriteString(`>`)
+	case Comment:
+		buf.WriteString(`<!--`)
+		buf.WriteString(string(node))
+		buf.WriteString(`-->`)
+	case Text:
+		textStringReplacer.WriteString(buf, string(node))
+	case CData:
+		buf.WriteString(`<![CDATA[`)
+		buf.WriteString(string(node))
+		buf.WriteString(`]]>`)
+	case EntityRef:
+		buf.WriteString(`&`)
+		buf.WriteString(string(node))
+		buf.WriteString(`;`)
+	case Elem:
+		node.writeString(buf)
+	case *Elem:
+		if node == nil {
+			buf.WriteString(`<nil>`)
+		} else {
+			node.writeString(buf)
+		}
+	case Nodes:
+		for _, node := range node {
+			writeNodeString(buf, node)
+		}
+	default:
+		fmt.Fprint(buf, node)
+	}
+}
+
+func (self Elem) writeString(buf *strings.Builder) {
+	name := self.Name.clark()
+	if self.Prefix != "" && self.Name.Space != "" {
+		name = self.Prefix + `:` + self.Name.Local
+	}
+
+	buf.WriteString(`<`)
+	buf.WriteString(name)
+	for _, attr := range self.Attrs {
+		buf.WriteString(` `)
+		if attr.Name.Space == NamespaceXMLNS {
+			buf.WriteString(NamespaceXMLNS + `:` + attr.Name.Local)
+		} else {
+			buf.WriteString(attr.Name.clark())
+		}
+		buf.WriteString(`="`)
+		attrStringReplacer.WriteString(buf, attr.Value)
+		buf.WriteString(`"`)
+	}
+
+	if len(self.Nodes) == 0 {
+		buf.WriteString(`/>`)
+		return
+	}
+
+	buf.WriteString(`>`)
+	writeNodeString(buf, self.Nodes)
+	buf.WriteString(`</`)
+	buf.WriteString(name)
+	buf.WriteString(`>`)
+}
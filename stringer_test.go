@@ -0,0 +1,26 @@
+package xt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<?one two?><!three><a:four xmlns:a="five" xmlns="six" seven="&quot;eight&quot;"><!--nine--><ten a:eleven="12"/>13 &amp; &lt;14&gt;<![CDATA[<15>]]></a:four>`))
+	require.NoError(t, err)
+
+	require.Equal(t, `<?one two?><!three><{five}four xmlns:a="five" xmlns="six" seven="&quot;eight&quot;"><!--nine--><{six}ten {five}eleven="12"/>13 &amp; &lt;14&gt;<![CDATA[<15>]]></{five}four>`, doc.String())
+
+	require.NoError(t, doc.ResolveNamespaces())
+	require.Equal(t, `<a:four xmlns:a="five" xmlns="six" seven="&quot;eight&quot;"><!--nine--><{six}ten {five}eleven="12"/>13 &amp; &lt;14&gt;<![CDATA[<15>]]></a:four>`, doc[2].(Elem).String())
+
+	require.Equal(t, `<></>`, Elem{Nodes: Nodes{Text(``)}}.String())
+	require.Equal(t, `<one/>`, fmt.Sprint(&Elem{Name: Name{Local: `one`}}))
+	require.Equal(t, `<?one?>`, Pi{Target: `one`}.String())
+	require.Equal(t, `&one;`, EntityRef(`one`).String())
+	require.Equal(t, `one<nil>`, Nodes{Text(`one`), (*Elem)(nil), nil}.String())
+	require.Equal(t, ``, Nodes(nil).String())
+	require.Equal(t, `[<one/> two]`, fmt.Sprint([]Node{Elem{Name: Name{Local: `one`}}, Text(`two`)}))
+}
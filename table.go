@@ -0,0 +1,46 @@
+package xt
+
+/*
+Extracts record-style data as a table, suitable for CSV export via
+`encoding/csv`. Finds all elements with exactly the given name, in document
+order, at any depth, and produces one row per element. Each row contains the
+values of the requested attributes, in the order of `attrNames`. Missing
+attributes produce empty strings. For example:
+
+	<item id="1" name="one" />
+	<item id="2" />
+
+	ToRecords(Name{Local: "item"}, []Name{{Local: "id"}, {Local: "name"}})
+	->
+	[][]string{{"1", "one"}, {"2", ""}}
+
+Header rows are not included.
+*/
+func (self Nodes) ToRecords(elemName Name, attrNames []Name) [][]string {
+	var out [][]string
+
+	walkPath(self, nil, func(_ Path, node Node) {
+		elem, ok := nodeElem(node)
+		if !ok || elem.Name != elemName {
+			return
+		}
+
+		row := make([]string, len(attrNames))
+		for ind, name := range attrNames {
+			row[ind], _ = elem.attrValue(name)
+		}
+		out = append(out, row)
+	})
+
+	return out
+}
+
+// Returns the value of the first attribute with exactly the given name.
+func (self Elem) attrValue(name Name) (string, bool) {
+	for _, attr := range self.Attrs {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
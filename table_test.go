@@ -0,0 +1,25 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToRecords(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `records.xml`))
+	require.NoError(t, err)
+
+	doc = append(doc, Elem{
+		Name:  Name{Local: `record`},
+		Attrs: []Attr{{Name: Name{Local: `kind`}, Value: `four`}, {Name: Name{Local: `id`}, Value: `4`}},
+	})
+
+	require.Equal(
+		t,
+		[][]string{{`1`, ``}, {`2`, ``}, {`3`, ``}, {`4`, `four`}},
+		doc.ToRecords(Name{Local: `record`}, []Name{{Local: `id`}, {Local: `kind`}}),
+	)
+
+	require.Nil(t, doc.ToRecords(Name{Local: `missing`}, []Name{{Local: `id`}}))
+}
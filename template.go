@@ -0,0 +1,126 @@
+package xt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options for `TemplateOptions.Template`.
+type TemplateOptions struct {
+	/**
+	Return an error when a placeholder has no corresponding key in the data.
+	By default, such placeholders are left as-is.
+	*/
+	ErrOnMissing bool
+}
+
+/*
+Shortcut for `TemplateOptions{}.Template`. Placeholders without corresponding
+data are left as-is, so this never fails.
+*/
+func (self Nodes) Template(data map[string]string) Nodes {
+	out, _ := TemplateOptions{}.Template(self, data)
+	return out
+}
+
+/*
+Returns a copy of the nodes where placeholders of the form `{{key}}` in text
+nodes and attribute values are replaced with the corresponding data values.
+Whitespace around the key is ignored, so `{{ key }}` is equivalent. Text is
+substituted as-is and escaped when encoding, so data values can't inject
+markup. Placeholders spanning several text nodes, such as those split by a
+comment, are not recognized. Unterminated "{{" is left as-is.
+*/
+func (self TemplateOptions) Template(nodes Nodes, data map[string]string) (Nodes, error) {
+	if nodes == nil {
+		return nil, nil
+	}
+
+	out := make(Nodes, 0, len(nodes))
+	for _, node := range nodes {
+		node, err := self.node(node, data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func (self TemplateOptions) node(node Node, data map[string]string) (Node, error) {
+	switch node := node.(type) {
+	case Text:
+		val, err := self.replace(string(node), data)
+		return Text(val), err
+
+	case Elem:
+		return self.elem(node, data)
+
+	case *Elem:
+		if node == nil {
+			return node, nil
+		}
+		elem, err := self.elem(*node, data)
+		return &elem, err
+
+	case Nodes:
+		return self.Template(node, data)
+
+	default:
+		return node, nil
+	}
+}
+
+func (self TemplateOptions) elem(elem Elem, data map[string]string) (Elem, error) {
+	if elem.Attrs != nil {
+		attrs := make([]Attr, len(elem.Attrs))
+		for ind, attr := range elem.Attrs {
+			val, err := self.replace(attr.Value, data)
+			if err != nil {
+				return elem, err
+			}
+			attr.Value = val
+			attrs[ind] = attr
+		}
+		elem.Attrs = attrs
+	}
+
+	nodes, err := self.Template(elem.Nodes, data)
+	elem.Nodes = nodes
+	return elem, err
+}
+
+func (self TemplateOptions) replace(src string, data map[string]string) (string, error) {
+	if !strings.Contains(src, `{{`) {
+		return src, nil
+	}
+
+	var buf strings.Builder
+	for {
+		start := strings.Index(src, `{{`)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(src[start+2:], `}}`)
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		key := strings.Trim(src[start+2:end], whitespace)
+		val, ok := data[key]
+		if !ok && self.ErrOnMissing {
+			return "", fmt.Errorf(`missing template data for key %q`, key)
+		}
+		if !ok {
+			val = src[start : end+2]
+		}
+
+		buf.WriteString(src[:start])
+		buf.WriteString(val)
+		src = src[end+2:]
+	}
+
+	buf.WriteString(src)
+	return buf.String(), nil
+}
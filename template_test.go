@@ -0,0 +1,28 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<letter to="{{email}}" id="{{ id }}"><p>Dear {{name}}, {{missing}} {{</p><!-- {{name}} --></letter>`))
+	require.NoError(t, err)
+
+	data := map[string]string{`email`: `one@example.com`, `id`: `2`, `name`: `<three>`}
+
+	out, err := MarshalOptions{}.Marshal(doc.Template(data))
+	require.NoError(t, err)
+	require.Equal(t, `<letter to="one@example.com" id="2"><p>Dear &lt;three&gt;, {{missing}} {{</p><!-- {{name}} --></letter>`, string(out))
+
+	_, err = TemplateOptions{ErrOnMissing: true}.Template(doc, data)
+	require.EqualError(t, err, `missing template data for key "missing"`)
+
+	data[`missing`] = `four`
+	nodes, err := TemplateOptions{ErrOnMissing: true}.Template(doc, data)
+	require.NoError(t, err)
+	require.Equal(t, Text(`Dear <three>, four {{`), nodes[0].(Elem).Nodes[0].(Elem).Nodes[0])
+
+	require.Equal(t, `{{email}}`, doc[0].(Elem).Attrs[0].Value, `must not mutate the original`)
+}
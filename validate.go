@@ -0,0 +1,186 @@
+package xt
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+An ID value used by more than one element, as reported by
+`Nodes.CheckUniqueIDs`. `Paths` are the locations of the conflicting elements,
+in document order.
+*/
+type Duplicate struct {
+	ID    string
+	Paths []Path
+}
+
+/*
+Reports ID values used by more than one element, in order of first occurrence.
+IDs are the values of `id` and `xml:id` attributes. XML requires IDs to be
+unique within a document, but `encoding/xml` doesn't check this.
+*/
+func (self Nodes) CheckUniqueIDs() []Duplicate {
+	var ids []string
+	paths := map[string][]Path{}
+
+	walkPath(self, nil, func(path Path, node Node) {
+		elem, ok := nodeElem(node)
+		if !ok {
+			return
+		}
+
+		for _, attr := range elem.Attrs {
+			if !isIDAttr(attr.Name) {
+				continue
+			}
+			if paths[attr.Value] == nil {
+				ids = append(ids, attr.Value)
+			}
+			paths[attr.Value] = append(paths[attr.Value], path.clone())
+		}
+	})
+
+	var out []Duplicate
+	for _, id := range ids {
+		if len(paths[id]) > 1 {
+			out = append(out, Duplicate{ID: id, Paths: paths[id]})
+		}
+	}
+	return out
+}
+
+func isIDAttr(name Name) bool {
+	return name.Local == `id` && (name.Space == "" || name.Space == NamespaceXML)
+}
+
+/*
+Structural problem reported by `Nodes.Validate`, such as an element with an
+empty name, which would fail to encode. `Path` is the location of the node.
+*/
+type ValidationError struct {
+	Path Path
+	Msg  string
+}
+
+func (self ValidationError) Error() string {
+	return self.Path.String() + `: ` + self.Msg
+}
+
+/*
+All problems reported by `Nodes.Validate`, in document order. Use `errors.As`
+to obtain it from the returned error.
+*/
+type ValidationErrors []ValidationError
+
+func (self ValidationErrors) Error() string {
+	var buf strings.Builder
+	for ind, err := range self {
+		if ind > 0 {
+			buf.WriteString(`; `)
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+/*
+Checks the entire tree for structural problems which would prevent encoding,
+and reports all of them at once as `ValidationErrors`, or returns nil. Useful
+before writing to an output stream, since encoding fails midway, leaving a
+partially-written document. Checks for:
+
+	Elements with an empty local name, and nil `*Elem`.
+	Attributes with an empty local name.
+	Processing instructions with an empty target, or with content containing "?>".
+	XML declarations which are not the first node.
+	Comments containing "--".
+	Doctypes with an empty name.
+	Entity references with an invalid name.
+
+Descends into elements and nested `Nodes`. For nodes inside nested `Nodes`,
+the path includes the index of the nested `Nodes`, followed by the index
+within it.
+*/
+func (self Nodes) Validate() error {
+	var state validation
+	state.nodes(self, nil)
+
+	if state.errs == nil {
+		return nil
+	}
+	return state.errs
+}
+
+type validation struct {
+	errs ValidationErrors
+	seen bool
+}
+
+func (self *validation) nodes(nodes Nodes, path Path) {
+	for ind, node := range nodes {
+		self.node(node, append(path, ind))
+	}
+}
+
+func (self *validation) node(node Node, path Path) {
+	report := func(msg string, args ...interface{}) {
+		self.errs = append(self.errs, ValidationError{Path: path.clone(), Msg: fmt.Sprintf(msg, args...)})
+	}
+
+	// Only the very first node may be an XML declaration.
+	first := !self.seen
+	if _, ok := node.(Nodes); !ok {
+		self.seen = true
+	}
+
+	switch node := node.(type) {
+	case Pi:
+		if node.Target == "" {
+			report(`processing instruction with empty target`)
+		}
+		if node.Target == `xml` && !first {
+			report(`XML declaration after other nodes`)
+		}
+		if strings.Contains(node.Content, `?>`) {
+			report(`processing instruction %q with content containing "?>"`, node.Target)
+		}
+
+	case Comment:
+		if strings.Contains(string(node), `--`) {
+			report(`comment containing "--"`)
+		}
+
+	case Doctype:
+		if node.Name == "" {
+			report(`doctype with empty name`)
+		}
+
+	case EntityRef:
+		if !isName(string(node)) {
+			report(`entity reference with invalid name %q`, string(node))
+		}
+
+	case *Elem:
+		if node == nil {
+			report(`nil element`)
+		}
+
+	case Nodes:
+		self.nodes(node, path)
+	}
+
+	elem, ok := nodeElem(node)
+	if !ok {
+		return
+	}
+	if elem.Name.Local == "" {
+		report(`element with empty name`)
+	}
+	for ind, attr := range elem.Attrs {
+		if attr.Name.Local == "" {
+			report(`attribute %d of element %q with empty name`, ind, elem.Name.Local)
+		}
+	}
+	self.nodes(elem.Nodes, path)
+}
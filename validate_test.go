@@ -0,0 +1,64 @@
+package xt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUniqueIDs(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<one id="a">
+  <two id="b" />
+  <three xml:id="a"><four id="c" /></three>
+  <five id="b" />
+  <six id="a" />
+</one>`))
+	require.NoError(t, err)
+
+	require.Equal(t, []Duplicate{
+		{ID: `a`, Paths: []Path{{0}, {0, 3}, {0, 7}}},
+		{ID: `b`, Paths: []Path{{0, 1}, {0, 5}}},
+	}, doc.CheckUniqueIDs())
+
+	require.Nil(t, expectedSimple.CheckUniqueIDs())
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, expectedSimple.Validate())
+	require.NoError(t, Nodes(nil).Validate())
+
+	nodes := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0"`},
+		Pi{Content: `one`},
+		Elem{Name: Name{Local: `two`}, Nodes: Nodes{
+			Elem{},
+			Comment(`three -- four`),
+			&Elem{Name: Name{Local: `five`}, Attrs: []Attr{{Name{Local: `six`}, ``}, {Name{Space: `seven`}, ``}}},
+		}},
+		Pi{Target: `eight`, Content: `?>`},
+	}
+
+	err := nodes.Validate()
+	require.EqualError(t, err, `nodes[1]: processing instruction with empty target; nodes[2].nodes[0]: element with empty name; nodes[2].nodes[1]: comment containing "--"; nodes[2].nodes[2]: attribute 1 of element "five" with empty name; nodes[3]: processing instruction "eight" with content containing "?>"`)
+
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 5)
+	require.Equal(t, ValidationError{Path: Path{2, 0}, Msg: `element with empty name`}, errs[1])
+}
+
+func TestValidateNested(t *testing.T) {
+	nodes := Nodes{E(`a`).C(Nodes{Elem{}})}
+
+	_, err := MarshalOptions{}.Marshal(nodes)
+	require.Error(t, err)
+	require.EqualError(t, nodes.Validate(), `nodes[0].nodes[0].nodes[0]: element with empty name`)
+
+	nodes = Nodes{
+		Nodes{Pi{Target: `xml`}},
+		Pi{Target: `xml`},
+		Elem{Name: Name{Local: `one`}, Nodes: Nodes{EntityRef(`two three`), (*Elem)(nil)}},
+	}
+	require.EqualError(t, nodes.Validate(), `nodes[1]: XML declaration after other nodes; nodes[2].nodes[0]: entity reference with invalid name "two three"; nodes[2].nodes[1]: nil element`)
+}
@@ -0,0 +1,116 @@
+package xt
+
+/*
+Traverses the nodes depth-first in document order (pre-order), calling `fn`
+with each node before its descendants. Descends into the child nodes of every
+`Elem` and `*Elem`, and into nested `Nodes`, which are also passed to `fn`.
+Stops at the first error returned by `fn`, and returns that error.
+
+For example, counting comments:
+
+	var count int
+	err := Walk(nodes, func(node Node) error {
+		if _, ok := node.(Comment); ok {
+			count++
+		}
+		return nil
+	})
+*/
+func Walk(nodes Nodes, fn func(Node) error) error {
+	for _, node := range nodes {
+		err := WalkNode(node, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Same as `Walk`, but starts at a single node, which is passed to `fn` before
+its descendants.
+*/
+func WalkNode(node Node, fn func(Node) error) error {
+	err := fn(node)
+	if err != nil {
+		return err
+	}
+
+	switch node := node.(type) {
+	case Elem:
+		return Walk(node.Nodes, fn)
+	case *Elem:
+		if node != nil {
+			return Walk(node.Nodes, fn)
+		}
+	case Nodes:
+		return Walk(node, fn)
+	}
+	return nil
+}
+
+/*
+Returns a copy of the nodes where each node is replaced with the result of
+`fn`, which is called bottom-up: the child nodes of each `Elem` and `*Elem`,
+and the contents of nested `Nodes`, are mapped before the node itself, and
+`fn` receives a copy with the mapped children. Returning a nil node deletes
+the node. Stops at the first error returned by `fn`, and returns that error.
+
+The input is not modified: elements are copied before replacing their child
+nodes, and `*Elem` is replaced with a pointer to a copy. Nodes which `fn`
+returns unchanged, including unchanged elements, may still be copies. For
+example, renaming `<b>` to `<strong>` and dropping comments:
+
+	out, err := nodes.Map(func(node Node) (Node, error) {
+		switch node := node.(type) {
+		case Comment:
+			return nil, nil
+		case Elem:
+			if node.Name.Local == "b" {
+				node.Name.Local = "strong"
+			}
+			return node, nil
+		}
+		return node, nil
+	})
+*/
+func (self Nodes) Map(fn func(Node) (Node, error)) (Nodes, error) {
+	if self == nil {
+		return nil, nil
+	}
+
+	out := make(Nodes, 0, len(self))
+	for _, node := range self {
+		node, err := mapNode(node, fn)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			out = append(out, node)
+		}
+	}
+	return out, nil
+}
+
+func mapNode(node Node, fn func(Node) (Node, error)) (Node, error) {
+	var err error
+
+	switch val := node.(type) {
+	case Elem:
+		val.Nodes, err = val.Nodes.Map(fn)
+		node = val
+	case *Elem:
+		if val != nil {
+			elem := *val
+			elem.Nodes, err = elem.Nodes.Map(fn)
+			node = &elem
+		}
+	case Nodes:
+		node, err = val.Map(fn)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return fn(node)
+}
@@ -0,0 +1,126 @@
+package xt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	two := &Elem{Name: Name{Local: `two`}, Nodes: Nodes{Text(`three`)}}
+	doc := Nodes{
+		Pi{Target: `xml`},
+		Elem{
+			Name:  Name{Local: `one`},
+			Nodes: Nodes{two, Comment(`four`), Nodes{Decl(`five`)}},
+		},
+		Text(`six`),
+	}
+
+	var visited []Node
+	require.NoError(t, Walk(doc, func(node Node) error {
+		visited = append(visited, node)
+		return nil
+	}))
+	require.Equal(t, Nodes{
+		doc[0], doc[1], two, Text(`three`), Comment(`four`), Nodes{Decl(`five`)}, Decl(`five`), Text(`six`),
+	}, Nodes(visited))
+
+	stop := errors.New(`stop`)
+	visited = nil
+	require.Equal(t, stop, Walk(doc, func(node Node) error {
+		visited = append(visited, node)
+		if node == Comment(`four`) {
+			return stop
+		}
+		return nil
+	}))
+	require.Len(t, visited, 5)
+
+	visited = nil
+	require.NoError(t, WalkNode(two, func(node Node) error {
+		visited = append(visited, node)
+		return nil
+	}))
+	require.Equal(t, Nodes{two, Text(`three`)}, Nodes(visited))
+}
+
+func TestNodesMap(t *testing.T) {
+	bold := &Elem{Name: Name{Local: `b`}, Nodes: Nodes{Text(`two`), Comment(`three`)}}
+	doc := Nodes{
+		Elem{
+			Name:  Name{Local: `p`},
+			Nodes: Nodes{Text(`one `), bold, Nodes{Comment(`four`), Elem{Name: Name{Local: `b`}}}},
+		},
+		Comment(`five`),
+	}
+	orig := doc.Clone()
+
+	var visited []string
+	out, err := doc.Map(func(node Node) (Node, error) {
+		visited = append(visited, nodeString(node))
+
+		switch node := node.(type) {
+		case Comment:
+			return nil, nil
+		case Elem:
+			if node.Name.Local == `b` {
+				node.Name.Local = `strong`
+			}
+			return node, nil
+		case *Elem:
+			if node.Name.Local == `b` {
+				node.Name.Local = `strong`
+			}
+			return node, nil
+		}
+		return node, nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, Nodes{
+		Elem{
+			Name: Name{Local: `p`},
+			Nodes: Nodes{
+				Text(`one `),
+				&Elem{Name: Name{Local: `strong`}, Nodes: Nodes{Text(`two`)}},
+				Nodes{Elem{Name: Name{Local: `strong`}}},
+			},
+		},
+	}, out)
+	require.Equal(t, orig, doc)
+	require.Equal(t, `b`, bold.Name.Local)
+	require.Equal(t, []string{
+		`one `, `two`, `<!--three-->`, `<b>two</b>`, `<!--four-->`, `<b/>`,
+		`<strong/>`, `<p>one <strong>two</strong><strong/></p>`, `<!--five-->`,
+	}, visited)
+
+	_, err = doc.Map(func(node Node) (Node, error) {
+		if _, ok := node.(Comment); ok {
+			return nil, errors.New(`comment`)
+		}
+		return node, nil
+	})
+	require.EqualError(t, err, `comment`)
+}
+
+// Same as the example in the doc of `Nodes.Map`.
+func TestNodesMapExample(t *testing.T) {
+	nodes := Nodes{Elem{Name: Name{Local: `p`}, Nodes: Nodes{Comment(`one`), Elem{Name: Name{Local: `b`}}}}}
+
+	out, err := nodes.Map(func(node Node) (Node, error) {
+		switch node := node.(type) {
+		case Comment:
+			return nil, nil
+		case Elem:
+			if node.Name.Local == "b" {
+				node.Name.Local = "strong"
+			}
+			return node, nil
+		}
+		return node, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, Nodes{Elem{Name: Name{Local: `p`}, Nodes: Nodes{Elem{Name: Name{Local: `strong`}}}}}, out)
+}
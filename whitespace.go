@@ -0,0 +1,45 @@
+package xt
+
+/*
+Decides whether whitespace in a sequence of sibling nodes is significant, and
+must be kept as-is, or is merely formatting, which may be trimmed, dropped or
+replaced with indentation. Used by `Nodes.Normalize`, `MarshalOptions` and
+`Equal`, which ensures that they agree on what is formatting. Custom policies
+may be supplied via `WhitespacePolicyFunc`.
+
+`parent` is the element containing the content, or nil for top-level nodes.
+*/
+type WhitespacePolicy interface {
+	Preserve(parent *Elem, content Nodes) bool
+}
+
+// Adapter for using a function as a `WhitespacePolicy`.
+type WhitespacePolicyFunc func(parent *Elem, content Nodes) bool
+
+func (self WhitespacePolicyFunc) Preserve(parent *Elem, content Nodes) bool {
+	return self(parent, content)
+}
+
+// Whitespace policy that treats all whitespace as significant.
+type PreserveAll struct{}
+
+func (PreserveAll) Preserve(*Elem, Nodes) bool { return true }
+
+/*
+Whitespace policy that treats all whitespace around text as formatting, even in
+mixed content. This is the default for `Nodes.Normalize`.
+*/
+type TrimFormatting struct{}
+
+func (TrimFormatting) Preserve(*Elem, Nodes) bool { return false }
+
+/*
+Whitespace policy that treats whitespace as significant only in mixed content,
+where non-whitespace text is interleaved with other nodes, and as formatting in
+element-only content. This is the default for `MarshalOptions`.
+*/
+type MixedContentAware struct{}
+
+func (MixedContentAware) Preserve(_ *Elem, content Nodes) bool {
+	return hasText(content)
+}
@@ -0,0 +1,80 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhitespacePolicies(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `mixed.xml`))
+	require.NoError(t, err)
+
+	test := func(policy WhitespacePolicy, normalized string, pretty string) {
+		t.Helper()
+
+		out, err := MarshalOptions{}.Marshal(doc.Normalize(NormalizeOptions{Whitespace: policy}))
+		require.NoError(t, err)
+		require.Equal(t, normalized, string(out))
+
+		out, err = MarshalOptions{Indent: `  `, Whitespace: policy}.Marshal(doc)
+		require.NoError(t, err)
+		require.Equal(t, pretty, string(out))
+	}
+
+	src := string(read(t, `mixed.xml`))
+
+	test(PreserveAll{}, src, src)
+
+	test(
+		TrimFormatting{},
+		`<doc><p>one<b>two</b>three</p><list><item>four</item></list></doc>`,
+		`<doc>
+  <p>
+    one
+    <b>two</b>
+    three
+  </p>
+  <list>
+    <item>four</item>
+  </list>
+</doc>`,
+	)
+
+	test(
+		MixedContentAware{},
+		`<doc><p> one <b>two</b> three </p><list><item> four </item></list></doc>`,
+		`<doc>
+  <p> one <b>two</b> three </p>
+  <list>
+    <item> four </item>
+  </list>
+</doc>`,
+	)
+}
+
+func TestWhitespacePolicyFunc(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `mixed.xml`))
+	require.NoError(t, err)
+
+	policy := WhitespacePolicyFunc(func(parent *Elem, _ Nodes) bool {
+		return parent != nil && parent.Name.Local == `item`
+	})
+
+	out, err := MarshalOptions{}.Marshal(doc.Normalize(NormalizeOptions{Whitespace: policy}))
+	require.NoError(t, err)
+	require.Equal(t, `<doc><p>one<b>two</b>three</p><list><item> four </item></list></doc>`, string(out))
+}
+
+func TestEqualWhitespace(t *testing.T) {
+	doc, err := Parser{}.Parse(read(t, `mixed.xml`))
+	require.NoError(t, err)
+
+	other, err := Parser{}.Parse([]byte(`<doc><p> one <b>two</b> three </p><list><item> four </item></list></doc>`))
+	require.NoError(t, err)
+
+	require.False(t, Equal(doc, other, EqualOptions{}))
+	require.False(t, Equal(doc, other, EqualOptions{Whitespace: PreserveAll{}}))
+	require.True(t, Equal(doc, other, EqualOptions{Whitespace: MixedContentAware{}}))
+	require.True(t, Equal(doc, other, EqualOptions{Whitespace: TrimFormatting{}}))
+}
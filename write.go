@@ -0,0 +1,757 @@
+package xt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Options for encoding nodes with the package's own XML writer, rather than
+`encoding/xml`. This supports formatting features not available via
+`xml.Encoder`. With the zero value, the output matches `xml.Marshal`, except
+that namespaced attributes reuse prefixes already declared in scope.
+*/
+type MarshalOptions struct {
+	/**
+	Indentation unit. When non-empty, the output is pretty-printed: elements
+	whose content consists only of other nodes (and whitespace) have each child
+	on its own line, with whitespace-only text nodes replaced by indentation.
+	Elements with mixed content, where text is interleaved with other nodes,
+	are written as-is, since adding whitespace would change their meaning.
+	*/
+	Indent string
+
+	/**
+	When positive and `Indent` is set, elements with more than this many
+	attributes have each attribute on its own line.
+	*/
+	WrapAttrs int
+
+	/**
+	Write a UTF-8 byte order mark before the output. Some Windows consumers
+	rely on it to detect the encoding. The writer always produces UTF-8, so
+	the BOM is always "\xef\xbb\xbf".
+	*/
+	EmitBOM bool
+
+	/**
+	Write tabs, newlines and carriage returns in attribute values literally,
+	rather than as character references. Useful for reproducing source
+	documents byte-for-byte. Note that XML parsers normalize such literal
+	characters in attribute values to spaces, unless the values are decoded
+	with `encoding/xml`, which doesn't normalize attributes.
+	*/
+	LiteralAttrWhitespace bool
+
+	/**
+	Decides which whitespace is significant when pretty-printing. Content
+	preserved by the policy is written as-is. In other content, whitespace-only
+	text nodes are replaced with indentation, and other text nodes are trimmed
+	and written on their own lines. Defaults to `MixedContentAware`.
+	*/
+	Whitespace WhitespacePolicy
+
+	/**
+	Omit namespace declarations that are redundant: declarations which rebind
+	a prefix, or the default namespace, to the URI already bound to it by an
+	ancestor, and repeated declarations of the same prefix within one element,
+	where the first one wins. Useful for trees assembled from fragments, where
+	every fragment declares its own namespaces.
+	*/
+	DedupeNamespaces bool
+
+	/**
+	Write elements without child nodes as self-closing tags such as `<one/>`,
+	rather than `<one></one>`. Elements containing only empty text nodes are
+	not self-closed; see `Nodes.NormalizeEmptyElements`. By default, empty
+	elements always have explicit start and end tags, like with `xml.Marshal`,
+	which never self-closes. This suits consumers which require them, such as
+	some older SOAP stacks.
+	*/
+	SelfClose bool
+
+	/**
+	Line ending for the newlines added when pretty-printing. Defaults to "\n".
+	Newlines within text nodes are written as-is.
+	*/
+	Newline string
+
+	/**
+	When set, called for each element before writing it, in document order.
+	The path consists of the local names of the element and its ancestors,
+	such as "/one/two". Useful for progress tracking or for collecting a
+	manifest of the written elements. The callback must not modify the
+	element.
+	*/
+	OnElement func(path string, elem *Elem)
+
+	/**
+	Write attributes sorted by local name, then by namespace, rather than in
+	their original order. Since attribute order is insignificant in XML, this
+	doesn't change the meaning of the document, and makes the output
+	consistent and easier to read and compare. Namespace declarations required
+	by the element's own name are still written first.
+	*/
+	SortAttrs bool
+
+	/**
+	Escape hatch for `SortAttrs`. When this returns true for an element, its
+	attributes are written in their original order, for consumers sensitive to
+	attribute order. Doesn't affect descendants.
+	*/
+	PreserveAttrOrderIn func(Elem) bool
+
+	/**
+	Write a space after the target of processing instructions with empty
+	content, such as `<?target ?>`. By default, like `encoding/xml`, the space
+	separating the target from the content is written only when the content
+	is non-empty: `<?target?>` and `<?target content?>`. Since decoding
+	discards the whitespace after the target, this allows to reproduce
+	documents which use the other form.
+	*/
+	SpaceEmptyPi bool
+
+	/**
+	Write text containing "<", ">" or "&" as CDATA sections, rather than
+	escaping these characters. Useful for embedding scripts or markup
+	snippets, which remain readable in the output. Text containing "]]>" is
+	split between several sections. Text containing characters which XML
+	doesn't allow, and which therefore can't be written literally, is escaped
+	as usual.
+	*/
+	TextAsCData bool
+}
+
+/*
+Shortcut for `MarshalOptions{Indent: indent, WrapAttrs: attrThreshold}.Marshal`.
+Pretty-prints the nodes, placing the attributes of elements with more than
+`attrThreshold` attributes on separate lines:
+
+	<one
+	  two="three"
+	  four="five"
+	  six="seven">
+	  <eight nine="ten"></eight>
+	</one>
+*/
+func MarshalIndentWrapped(nodes Nodes, indent string, attrThreshold int) ([]byte, error) {
+	return MarshalOptions{Indent: indent, WrapAttrs: attrThreshold}.Marshal(nodes)
+}
+
+/*
+Shortcut for `MarshalOptions{Indent: indent, SortAttrs: true}.Marshal`.
+Pretty-prints the nodes, writing the attributes of each element in
+alphabetical order. For control over which elements are sorted, use
+`MarshalOptions.PreserveAttrOrderIn`.
+*/
+func MarshalIndentSortedAttrs(nodes Nodes, indent string) ([]byte, error) {
+	return MarshalOptions{Indent: indent, SortAttrs: true}.Marshal(nodes)
+}
+
+/*
+Shortcut for `MarshalOptions{Indent: indent}.Marshal`. Pretty-prints the
+nodes, indenting the children of elements whose content has no
+non-whitespace text. Mixed content, where text is interleaved with other
+nodes, is written as-is, since adding whitespace would change its meaning:
+
+	<one>
+	  <two>three <b>four</b> five</two>
+	</one>
+
+See `MixedContentAware`. Unlike `xml.MarshalIndent`, this never adds
+whitespace to text content.
+*/
+func (self Nodes) Pretty(indent string) ([]byte, error) {
+	return MarshalOptions{Indent: indent}.Marshal(self)
+}
+
+// Encodes the node as XML, returning the resulting bytes.
+func (self MarshalOptions) Marshal(node Node) ([]byte, error) {
+	var buf bytes.Buffer
+	err := self.Write(&buf, node)
+	return buf.Bytes(), err
+}
+
+// Encodes the node as XML, writing to the given writer.
+func (self MarshalOptions) Write(out io.Writer, node Node) error {
+	return self.write(out, node, nil)
+}
+
+/*
+Encodes the node with the given namespace declarations already in scope, such
+as when encoding the content of an element.
+*/
+func (self MarshalOptions) write(out io.Writer, node Node, scope []nsDecl) error {
+	wri := writer{MarshalOptions: self, out: bufio.NewWriter(out), scope: scope}
+	if self.EmitBOM {
+		_, _ = wri.out.Write(utf8Bom)
+	}
+
+	err := wri.node(node, false)
+	if err != nil {
+		return err
+	}
+	return wri.out.Flush()
+}
+
+type writer struct {
+	MarshalOptions
+	out   *bufio.Writer
+	depth int
+	size  int
+	scope []nsDecl
+	seq   int
+	path  []string
+}
+
+type nsDecl struct{ prefix, uri string }
+
+type attrOut struct{ name, value, raw string }
+
+func (self *writer) nodes(parent *Elem, nodes Nodes, inline bool) error {
+	if inline || self.Indent == "" || self.whitespace().Preserve(parent, nodes) {
+		for _, node := range nodes {
+			err := self.node(node, true)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, node := range nodes {
+		if isWhitespaceText(node) {
+			continue
+		}
+		self.newline()
+
+		text, ok := node.(Text)
+		if ok {
+			self.text(strings.Trim(string(text), whitespace))
+			continue
+		}
+
+		err := self.node(node, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *writer) node(node Node, inline bool) error {
+	switch node := node.(type) {
+	case Pi:
+		return self.pi(node)
+	case Decl:
+		self.str(`<!`)
+		self.str(string(node))
+		self.str(`>`)
+		return nil
+	case Doctype:
+		if node.Name == "" {
+			return fmt.Errorf(`can't encode XML doctype with empty name`)
+		}
+		self.str(`<!`)
+		self.str(string(node.Decl()))
+		self.str(`>`)
+		return nil
+	case Comment:
+		return self.comment(node)
+	case Text:
+		self.text(string(node))
+		return nil
+	case CData:
+		self.cdata(node)
+		return nil
+	case EntityRef:
+		if !isName(string(node)) {
+			return fmt.Errorf(`can't encode XML entity reference with invalid name %q`, string(node))
+		}
+		self.str(`&`)
+		self.str(string(node))
+		self.str(`;`)
+		return nil
+	case Elem:
+		return self.elem(node, inline)
+	case *Elem:
+		if node == nil {
+			return fmt.Errorf(`can't XML-encode nil *Elem`)
+		}
+		return self.elem(*node, inline)
+	case Nodes:
+		return self.nodes(nil, node, inline)
+	}
+
+	var buf bytes.Buffer
+	err := xml.NewEncoder(&buf).Encode(node)
+	if err != nil {
+		return err
+	}
+	self.str(buf.String())
+	return nil
+}
+
+func (self *writer) pi(node Pi) error {
+	if node.Target == "" {
+		return fmt.Errorf(`can't encode XML processing instruction with empty target`)
+	}
+	if node.Target == "xml" && self.size > 0 {
+		return fmt.Errorf(`can't encode XML declaration after other nodes`)
+	}
+	if strings.Contains(node.Content, `?>`) {
+		return fmt.Errorf(`can't encode XML processing instruction containing "?>"`)
+	}
+
+	self.str(`<?`)
+	self.str(node.Target)
+	if node.Content != "" || self.SpaceEmptyPi {
+		self.str(` `)
+		self.str(node.Content)
+	}
+	self.str(`?>`)
+	return nil
+}
+
+func (self *writer) comment(node Comment) error {
+	if strings.Contains(string(node), `-->`) {
+		return fmt.Errorf(`can't encode XML comment containing "-->"`)
+	}
+	self.str(`<!--`)
+	self.str(string(node))
+	self.str(`-->`)
+	return nil
+}
+
+// Writes text, escaping it or wrapping it in CDATA sections.
+func (self *writer) text(val string) {
+	if self.TextAsCData && strings.ContainsAny(val, `<>&`) && isValidText(val) {
+		self.cdata(CData(val))
+		return
+	}
+	self.escape(val, false)
+}
+
+/*
+Writes a literal CDATA section. Since CDATA sections can't contain "]]>", it's
+split between two adjacent sections, which decode into the same text.
+*/
+func (self *writer) cdata(node CData) {
+	self.str(`<![CDATA[`)
+	self.str(strings.Replace(string(node), `]]>`, `]]]]><![CDATA[>`, -1))
+	self.str(`]]>`)
+}
+
+func (self *writer) elem(elem Elem, inline bool) error {
+	if elem.Name.Local == "" {
+		return fmt.Errorf(`can't XML-encode %T with empty name`, elem)
+	}
+
+	scopeLen := len(self.scope)
+	defer func() { self.scope = self.scope[:scopeLen] }()
+
+	if self.OnElement != nil {
+		self.path = append(self.path, elem.Name.Local)
+		defer func() { self.path = self.path[:len(self.path)-1] }()
+		self.OnElement(`/`+strings.Join(self.path, `/`), &elem)
+	}
+
+	name := elem.Name.Local
+	if elem.Prefix != "" && elem.Name.Space != "" {
+		name = elem.Prefix + `:` + name
+	}
+	attrs := self.attrs(elem)
+
+	self.str(`<`)
+	self.str(name)
+
+	wrap := !inline && self.Indent != "" && self.WrapAttrs > 0 && len(attrs) > self.WrapAttrs
+	if wrap {
+		self.depth++
+	}
+	for _, attr := range attrs {
+		if wrap {
+			self.newline()
+		} else {
+			self.str(` `)
+		}
+		self.str(attr.name)
+		self.str(`="`)
+		if attr.raw != "" {
+			self.str(attr.raw)
+		} else {
+			self.escape(attr.value, true)
+		}
+		self.str(`"`)
+	}
+	if wrap {
+		self.depth--
+	}
+
+	if self.SelfClose && len(elem.Nodes) == 0 {
+		self.str(`/>`)
+		return nil
+	}
+	self.str(`>`)
+
+	inline = inline || self.Indent == "" || self.whitespace().Preserve(&elem, elem.Nodes)
+
+	// Text-only content is trimmed but kept on the same line as the tags.
+	if !inline && isAllText(elem.Nodes) {
+		self.text(strings.Trim(elem.Nodes.ownText(), whitespace))
+		inline = true
+	} else {
+		self.depth++
+		err := self.nodes(&elem, elem.Nodes, inline)
+		self.depth--
+		if err != nil {
+			return err
+		}
+	}
+
+	if !inline && !isBlank(elem.Nodes) {
+		self.newline()
+	}
+	self.str(`</`)
+	self.str(name)
+	self.str(`>`)
+	return nil
+}
+
+/*
+Collects the attributes to write, including namespace declarations required
+by the element and attribute names, and pushes the element's namespace
+declarations into scope.
+*/
+func (self *writer) attrs(elem Elem) []attrOut {
+	raw := rawAttrLookup(elem)
+	if self.SortAttrs && !(self.PreserveAttrOrderIn != nil && self.PreserveAttrOrderIn(elem)) {
+		elem.Attrs = sortedAttrs(elem.Attrs)
+	}
+
+	out := make([]attrOut, 0, len(elem.Attrs)+1)
+	outer := len(self.scope)
+
+	prefixed := elem.Prefix != "" && elem.Name.Space != ""
+
+	if elem.Name.Space != "" && !prefixed && !hasExactAttr(elem.Attrs, "", NamespaceXMLNS, elem.Name.Space) {
+		if !(self.DedupeNamespaces && self.isRedundant("", elem.Name.Space, outer)) {
+			self.scope = append(self.scope, nsDecl{"", elem.Name.Space})
+			out = append(out, attrOut{NamespaceXMLNS, elem.Name.Space, ""})
+		}
+	}
+
+	var skip []bool
+	if self.DedupeNamespaces {
+		skip = make([]bool, len(elem.Attrs))
+	}
+
+	for ind, attr := range elem.Attrs {
+		prefix, uri, ok := attr.DeclaredPrefix()
+		if !ok {
+			continue
+		}
+		if skip != nil && (self.isRedundant(prefix, uri, outer) || self.isDeclaredSince(prefix, outer)) {
+			skip[ind] = true
+			continue
+		}
+		self.scope = append(self.scope, nsDecl{prefix, uri})
+	}
+
+	if prefixed && !self.isRedundant(elem.Prefix, elem.Name.Space, len(self.scope)) {
+		self.scope = append(self.scope, nsDecl{elem.Prefix, elem.Name.Space})
+		out = append(out, attrOut{NamespaceXMLNS + `:` + elem.Prefix, elem.Name.Space, ""})
+	}
+
+	for ind, attr := range elem.Attrs {
+		name := attr.Name
+		if name.Local == "" || (skip != nil && skip[ind]) {
+			continue
+		}
+
+		switch name.Space {
+		case "":
+			out = append(out, attrOut{name.Local, attr.Value, raw(attr)})
+
+		case NamespaceXMLNS:
+			out = append(out, attrOut{NamespaceXMLNS + `:` + name.Local, attr.Value, raw(attr)})
+
+		case NamespaceXML:
+			out = append(out, attrOut{`xml:` + name.Local, attr.Value, raw(attr)})
+
+		default:
+			prefix, ok := self.prefix(name.Space)
+			if !ok {
+				prefix = self.newPrefix(name.Space)
+				self.scope = append(self.scope, nsDecl{prefix, name.Space})
+				out = append(out, attrOut{NamespaceXMLNS + `:` + prefix, name.Space, ""})
+			}
+			out = append(out, attrOut{prefix + `:` + name.Local, attr.Value, raw(attr)})
+		}
+	}
+
+	return out
+}
+
+// Returns a copy of the attributes sorted by local name, then by namespace.
+func sortedAttrs(attrs []Attr) []Attr {
+	out := append([]Attr(nil), attrs...)
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].Name.Local != out[b].Name.Local {
+			return out[a].Name.Local < out[b].Name.Local
+		}
+		return out[a].Name.Space < out[b].Name.Space
+	})
+	return out
+}
+
+// Finds the innermost prefix declared for the given namespace URI.
+func (self *writer) prefix(uri string) (string, bool) {
+	for ind := len(self.scope) - 1; ind >= 0; ind-- {
+		decl := self.scope[ind]
+		if decl.prefix != "" && decl.uri == uri && self.isBound(decl.prefix, uri, ind) {
+			return decl.prefix, true
+		}
+	}
+	return "", false
+}
+
+// True if the prefix is not redeclared by a later declaration in scope.
+func (self *writer) isBound(prefix, uri string, index int) bool {
+	for _, decl := range self.scope[index+1:] {
+		if decl.prefix == prefix && decl.uri != uri {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Generates a prefix for an undeclared attribute namespace, following the same
+conventions as `encoding/xml`.
+*/
+func (self *writer) newPrefix(uri string) string {
+	prefix := strings.TrimRight(uri, `/`)
+	if ind := strings.LastIndex(prefix, `/`); ind >= 0 {
+		prefix = prefix[ind+1:]
+	}
+	if prefix == "" || !isName(prefix) || strings.Contains(prefix, `:`) {
+		prefix = `_`
+	}
+	if len(prefix) >= 3 && strings.EqualFold(prefix[:3], `xml`) {
+		prefix = `_` + prefix
+	}
+	if self.isDeclared(prefix) {
+		for {
+			self.seq++
+			next := prefix + `_` + strconv.Itoa(self.seq)
+			if !self.isDeclared(next) {
+				prefix = next
+				break
+			}
+		}
+	}
+	return prefix
+}
+
+/*
+True if the innermost binding of the prefix among the declarations before the
+given scope index is the given URI. Empty prefix stands for the default
+namespace.
+*/
+func (self *writer) isRedundant(prefix, uri string, outer int) bool {
+	for ind := outer - 1; ind >= 0; ind-- {
+		decl := self.scope[ind]
+		if decl.prefix == prefix {
+			return decl.uri == uri
+		}
+	}
+	return false
+}
+
+// True if the prefix is declared at or after the given scope index.
+func (self *writer) isDeclaredSince(prefix string, index int) bool {
+	for _, decl := range self.scope[index:] {
+		if decl.prefix == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *writer) isDeclared(prefix string) bool {
+	for _, decl := range self.scope {
+		if decl.prefix == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *writer) whitespace() WhitespacePolicy {
+	if self.Whitespace != nil {
+		return self.Whitespace
+	}
+	return MixedContentAware{}
+}
+
+func (self *writer) newline() {
+	if self.size > 0 {
+		self.str(self.MarshalOptions.newline())
+	}
+	for ind := 0; ind < self.depth; ind++ {
+		self.str(self.Indent)
+	}
+}
+
+func (self *writer) str(val string) {
+	size, _ := self.out.WriteString(val)
+	self.size += size
+}
+
+/*
+Escapes text or an attribute value, following the same rules as
+`encoding/xml`. Invalid characters are replaced with U+FFFD.
+*/
+func (self *writer) escape(val string, attr bool) {
+	last := 0
+	for ind := 0; ind < len(val); {
+		char, width := utf8.DecodeRuneInString(val[ind:])
+		next := ind + width
+
+		var esc string
+		switch char {
+		case '"':
+			esc = `&#34;`
+		case '\'':
+			esc = `&#39;`
+		case '&':
+			esc = `&amp;`
+		case '<':
+			esc = `&lt;`
+		case '>':
+			esc = `&gt;`
+		case '\t':
+			if attr && self.LiteralAttrWhitespace {
+				ind = next
+				continue
+			}
+			esc = `&#x9;`
+		case '\n':
+			if !attr || self.LiteralAttrWhitespace {
+				ind = next
+				continue
+			}
+			esc = `&#xA;`
+		case '\r':
+			if attr && self.LiteralAttrWhitespace {
+				ind = next
+				continue
+			}
+			esc = `&#xD;`
+		default:
+			if !isInCharacterRange(char) || (char == utf8.RuneError && width == 1) {
+				esc = "\uFFFD"
+				break
+			}
+			ind = next
+			continue
+		}
+
+		self.str(val[last:ind])
+		self.str(esc)
+		last = next
+		ind = next
+	}
+	self.str(val[last:])
+}
+
+func hasText(nodes Nodes) bool {
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case Text:
+			if !isWhitespace(string(node)) {
+				return true
+			}
+		case CData, EntityRef:
+			return true
+		}
+	}
+	return false
+}
+
+func isAllText(nodes Nodes) bool {
+	for _, node := range nodes {
+		_, ok := node.(Text)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// True if the nodes are empty or consist only of whitespace text.
+func isBlank(nodes Nodes) bool {
+	for _, node := range nodes {
+		if !isWhitespaceText(node) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWhitespaceText(node Node) bool {
+	val, ok := node.(Text)
+	return ok && isWhitespace(string(val))
+}
+
+func isWhitespace(val string) bool {
+	return strings.Trim(val, whitespace) == ""
+}
+
+// True if the text can be written literally, without replacement characters.
+func isValidText(val string) bool {
+	for ind, char := range val {
+		if !isInCharacterRange(char) {
+			return false
+		}
+		if char == utf8.RuneError {
+			_, width := utf8.DecodeRuneInString(val[ind:])
+			if width == 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Same as the unexported function in `encoding/xml`.
+func isInCharacterRange(char rune) bool {
+	return char == 0x09 ||
+		char == 0x0A ||
+		char == 0x0D ||
+		char >= 0x20 && char <= 0xD7FF ||
+		char >= 0xE000 && char <= 0xFFFD ||
+		char >= 0x10000 && char <= 0x10FFFF
+}
+
+// Simplified version of the name check in `encoding/xml`.
+func isName(val string) bool {
+	for ind, char := range val {
+		if char == '_' || char == ':' || char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z' || char > 0x7F {
+			continue
+		}
+		if ind > 0 && (char == '-' || char == '.' || char >= '0' && char <= '9') {
+			continue
+		}
+		return false
+	}
+	return val != ""
+}
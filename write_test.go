@@ -0,0 +1,322 @@
+package xt
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalOptionsMatchesXmlMarshal(t *testing.T) {
+	test := func(path string) {
+		t.Helper()
+
+		var doc Nodes
+		require.NoError(t, doc.Decode(xml.NewDecoder(bytes.NewReader(read(t, path)))))
+
+		expected, err := xml.Marshal(doc)
+		require.NoError(t, err)
+
+		out, err := MarshalOptions{}.Marshal(doc)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(out))
+	}
+
+	test(`simple.xml`)
+	test(`ns_inlined.xml`)
+	test(`ns_aliased.xml`)
+}
+
+func TestMarshalOptionsEscape(t *testing.T) {
+	doc := Nodes{
+		Elem{
+			Name:  Name{Local: `one`},
+			Attrs: []Attr{{Name: Name{Local: `two`}, Value: "<\"three\"\n&\t>"}},
+			Nodes: Nodes{Text("<'four'\n&\t\x00>")},
+		},
+	}
+
+	expected, err := xml.Marshal(doc)
+	require.NoError(t, err)
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(out))
+}
+
+func TestMarshalOptionsCData(t *testing.T) {
+	doc := Nodes{Elem{
+		Name:  Name{Local: `one`},
+		Nodes: Nodes{CData(`two ]]> three`)},
+	}}
+
+	out, err := MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one><![CDATA[two ]]]]><![CDATA[> three]]></one>`, string(out))
+
+	parsed, err := Parser{}.Parse(out)
+	require.NoError(t, err)
+	require.Equal(t, Text(`two ]]> three`), Text(parsed[0].(Elem).Nodes.ownText()))
+
+	src, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `[{"type":"elem","name":{"local":"one"},"nodes":[{"type":"cdata","content":"two ]]\u003e three"}]}]`, string(src))
+
+	var decoded Nodes
+	require.NoError(t, json.Unmarshal(src, &decoded))
+	require.Equal(t, doc, decoded)
+}
+
+func TestMarshalIndentWrapped(t *testing.T) {
+	doc := Nodes{
+		Pi{Target: `xml`, Content: `version="1.0"`},
+		Text("\n"),
+		Elem{
+			Name: Name{Local: `config`},
+			Attrs: []Attr{
+				{Name: Name{Local: `one`}, Value: `1`},
+				{Name: Name{Local: `two`}, Value: `2`},
+				{Name: Name{Local: `three`}, Value: `3`},
+				{Name: Name{Local: `four`}, Value: `4`},
+				{Name: Name{Local: `five`}, Value: `5`},
+			},
+			Nodes: Nodes{
+				Text("\n  "),
+				Elem{
+					Name: Name{Local: `item`},
+					Attrs: []Attr{
+						{Name: Name{Local: `six`}, Value: `6`},
+						{Name: Name{Local: `seven`}, Value: `7`},
+					},
+				},
+				Comment(` eight `),
+				Elem{
+					Name:  Name{Local: `mixed`},
+					Nodes: Nodes{Text(` nine `), Elem{Name: Name{Local: `ten`}}, Text(` eleven `)},
+				},
+				Text("\n"),
+			},
+		},
+	}
+
+	out, err := MarshalIndentWrapped(doc, `  `, 3)
+	require.NoError(t, err)
+
+	require.Equal(t, `<?xml version="1.0"?>
+<config
+  one="1"
+  two="2"
+  three="3"
+  four="4"
+  five="5">
+  <item six="6" seven="7"></item>
+  <!-- eight -->
+  <mixed> nine <ten></ten> eleven </mixed>
+</config>`, string(out))
+}
+
+func TestPretty(t *testing.T) {
+	doc, err := Parse([]byte(`<one> <two>three <b>four</b> five</two><six><seven/></six></one>`))
+	require.NoError(t, err)
+
+	out, err := doc.Pretty(`  `)
+	require.NoError(t, err)
+	require.Equal(t, `<one>
+  <two>three <b>four</b> five</two>
+  <six>
+    <seven></seven>
+  </six>
+</one>`, string(out))
+
+	out, err = xml.MarshalIndent(doc, ``, `  `)
+	require.NoError(t, err)
+	require.Contains(t, string(out), "<two>three \n")
+}
+
+func TestMarshalIndentSortedAttrs(t *testing.T) {
+	doc := Nodes{Elem{
+		Name: Name{Local: `one`},
+		Attrs: []Attr{
+			{Name: Name{Local: `two`}, Value: `2`},
+			{Name: Name{Space: `seven`, Local: `three`}, Value: `3`},
+			{Name: Name{Local: `three`}, Value: `3`},
+			{Name: Name{Local: `four`}, Value: `4`},
+		},
+		Nodes: Nodes{Elem{
+			Name: Name{Local: `five`},
+			Attrs: []Attr{
+				{Name: Name{Local: `six`}, Value: `6`},
+				{Name: Name{Local: `eight`}, Value: `8`},
+			},
+		}},
+	}}
+
+	out, err := MarshalIndentSortedAttrs(doc, `  `)
+	require.NoError(t, err)
+	require.Equal(t, `<one four="4" three="3" xmlns:seven="seven" seven:three="3" two="2">
+  <five eight="8" six="6"></five>
+</one>`, string(out))
+
+	out, err = MarshalOptions{
+		SortAttrs:           true,
+		PreserveAttrOrderIn: func(elem Elem) bool { return elem.Name.Local == `five` },
+	}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one four="4" three="3" xmlns:seven="seven" seven:three="3" two="2"><five six="6" eight="8"></five></one>`, string(out))
+
+	require.Equal(t, Name{Local: `two`}, doc[0].(Elem).Attrs[0].Name)
+}
+
+func TestMarshalOptionsSpaceEmptyPi(t *testing.T) {
+	test := func(opts MarshalOptions, src string) {
+		t.Helper()
+
+		doc, err := Parser{}.Parse([]byte(src))
+		require.NoError(t, err)
+
+		out, err := opts.Marshal(doc)
+		require.NoError(t, err)
+		require.Equal(t, src, string(out))
+	}
+
+	test(MarshalOptions{}, `<?one?><?two three?><four></four>`)
+	test(MarshalOptions{SpaceEmptyPi: true}, `<?one ?><?two three?><four></four>`)
+
+	out, err := xml.Marshal(Nodes{Pi{Target: `one`}, Pi{Target: `two`, Content: `three`}})
+	require.NoError(t, err)
+	require.Equal(t, `<?one?><?two three?>`, string(out))
+}
+
+func TestMarshalOptionsTextAsCData(t *testing.T) {
+	doc := Nodes{Elem{
+		Name: Name{Local: `one`},
+		Nodes: Nodes{
+			Text(`two`),
+			Elem{Name: Name{Local: `script`}, Nodes: Nodes{Text(`if (a < b && c > d) {}`)}},
+			Elem{Name: Name{Local: `three`}, Nodes: Nodes{Text(`four ]]> five`)}},
+			Elem{Name: Name{Local: `six`}, Nodes: Nodes{Text("<\x00>")}},
+		},
+	}}
+
+	out, err := MarshalOptions{TextAsCData: true}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one>two<script><![CDATA[if (a < b && c > d) {}]]></script><three><![CDATA[four ]]]]><![CDATA[> five]]></three><six>&lt;`+"\uFFFD"+`&gt;</six></one>`, string(out))
+
+	decoded, err := Parser{}.Parse(out)
+	require.NoError(t, err)
+	require.Equal(t, `twoif (a < b && c > d) {}four ]]> five<`+"\uFFFD"+`>`, decoded.TextContent())
+
+	out, err = MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	expected, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(out))
+}
+
+func TestMarshalOptionsEmitBOM(t *testing.T) {
+	doc := Nodes{Pi{Target: `xml`, Content: `version="1.0"`}, Elem{Name: Name{Local: `one`}}}
+
+	out, err := MarshalOptions{EmitBOM: true}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, "\xef\xbb\xbf<?xml version=\"1.0\"?><one></one>", string(out))
+
+	var buf bytes.Buffer
+	require.NoError(t, MarshalOptions{}.Write(&buf, doc))
+	require.Equal(t, `<?xml version="1.0"?><one></one>`, buf.String())
+}
+
+func TestMarshalOptionsEmptyElements(t *testing.T) {
+	doc := Nodes{Elem{
+		Name:  Name{Local: `one`},
+		Attrs: []Attr{{Name: Name{Local: `two`}, Value: `three`}},
+		Nodes: Nodes{Elem{Name: Name{Local: `four`}, Attrs: []Attr{{Name: Name{Local: `five`}, Value: `six`}}}},
+	}}
+
+	out, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one two="three"><four five="six"></four></one>`, string(out))
+
+	out, err = MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one two="three"><four five="six"></four></one>`, string(out))
+
+	out, err = MarshalOptions{SelfClose: true}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one two="three"><four five="six"/></one>`, string(out))
+}
+
+func TestMarshalOptionsLiteralAttrWhitespace(t *testing.T) {
+	src := "<one two=\"three\n\tfour\"> five\n\tsix </one>"
+
+	doc, err := Parser{}.Parse([]byte(src))
+	require.NoError(t, err)
+	require.Equal(t, "three\n\tfour", doc[0].(Elem).Attrs[0].Value)
+
+	out, err := MarshalOptions{LiteralAttrWhitespace: true}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, "<one two=\"three\n\tfour\"> five\n&#x9;six </one>", string(out))
+
+	out, err = MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one two="three&#xA;&#x9;four"> five`+"\n"+`&#x9;six </one>`, string(out))
+}
+
+func TestMarshalOptionsDedupeNamespaces(t *testing.T) {
+	doc := Nodes{Elem{
+		Name: Name{Local: `one`},
+		Attrs: []Attr{
+			{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_p`},
+			{Name: Name{Local: NamespaceXMLNS}, Value: `ns_default`},
+		},
+		Nodes: Nodes{
+			Elem{
+				Name: Name{Space: `ns_default`, Local: `two`},
+				Attrs: []Attr{
+					{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_p`},
+					{Name: Name{Space: NamespaceXMLNS, Local: `q`}, Value: `ns_q`},
+					{Name: Name{Space: NamespaceXMLNS, Local: `q`}, Value: `ns_q`},
+					{Name: Name{Space: `ns_p`, Local: `three`}, Value: `four`},
+				},
+			},
+			Elem{
+				Name:  Name{Local: `five`},
+				Attrs: []Attr{{Name: Name{Space: NamespaceXMLNS, Local: `p`}, Value: `ns_other`}},
+			},
+		},
+	}}
+
+	out, err := MarshalOptions{DedupeNamespaces: true}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one xmlns:p="ns_p" xmlns="ns_default"><two xmlns:q="ns_q" p:three="four"></two><five xmlns:p="ns_other"></five></one>`, string(out))
+
+	out, err = MarshalOptions{}.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, `<one xmlns:p="ns_p" xmlns="ns_default"><two xmlns="ns_default" xmlns:p="ns_p" xmlns:q="ns_q" xmlns:q="ns_q" p:three="four"></two><five xmlns:p="ns_other"></five></one>`, string(out))
+}
+
+func TestMarshalOptionsOnElement(t *testing.T) {
+	var paths []string
+	var attrs []string
+
+	opts := MarshalOptions{OnElement: func(path string, elem *Elem) {
+		paths = append(paths, path)
+		attrs = append(attrs, elem.Attrs[0].Value)
+	}}
+
+	out, err := opts.Marshal(expectedSimple)
+	require.NoError(t, err)
+	require.Equal(t, string(read(t, `simple.xml`)), string(out))
+
+	require.Equal(t, []string{`/one`, `/one/six`, `/one/six/nine`}, paths)
+	require.Equal(t, []string{`three`, `eight`, `eleven`}, attrs)
+}
+
+func TestMarshalOptionsNilElem(t *testing.T) {
+	_, err := MarshalOptions{}.Marshal(Nodes{(*Elem)(nil)})
+	require.EqualError(t, err, `can't XML-encode nil *Elem`)
+
+	_, err = MarshalOptions{}.Marshal(Nodes{E(`one`).C((*Elem)(nil))})
+	require.EqualError(t, err, `can't XML-encode nil *Elem`)
+}
@@ -0,0 +1,516 @@
+package xt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Selects elements matching a minimal subset of XPath, returning them in
+document order. Supported syntax:
+
+	/one/two                 child steps, starting at the top level
+	one/two                  same as above; paths are always evaluated from the top level
+	//two                    descendants at any depth
+	/one//two                descendants of "one" at any depth
+	*                        any element
+	p:two                    element "two" in the namespace bound to prefix "p"
+	p:*                      any element in the namespace bound to prefix "p"
+	two/ancestor::one        ancestors of "two" named "one"
+	two/ancestor-or-self::*  "two" and all its ancestors
+	/one/two[2]              second "two" child of "one"
+	//two[1]                 first "two" child of each parent
+	//two[@id]               "two" elements with an "id" attribute
+	//two[@id='three']       "two" elements whose "id" is "three"; also with double quotes
+	/one/@id                 value of the "id" attribute of "one"
+	//two/@*                 values of all attributes of all "two" elements
+	//@id                    values of all "id" attributes at any depth
+
+Since decoded names store namespace URIs rather than prefixes, prefixes in the
+path are resolved via `namespaces`, which maps prefixes to URIs. Unprefixed
+names match by local name in any namespace. Unbound prefixes are an error.
+
+Predicates can be chained, such as `two[@id][2]`, and apply in order. In the
+"ancestor::" axes, position 1 is the nearest ancestor. Unprefixed attribute
+names also match any namespace. An attribute step must be the last step.
+
+The returned nodes are the matching nodes as stored in the tree: `Elem` or
+`*Elem`. For an attribute step, they're the attribute values as `Text`.
+*/
+func (self Nodes) Select(path string, namespaces map[string]string) (Nodes, error) {
+	matches, err := self.selectPath(path, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(Nodes, 0, len(matches))
+	for _, match := range matches {
+		out = append(out, match.node)
+	}
+	return out, nil
+}
+
+/*
+Shortcut for `Nodes.Select` without namespace prefixes. Elements are matched
+by local name in any namespace.
+*/
+func (self Nodes) XPath(expr string) (Nodes, error) {
+	return self.Select(expr, nil)
+}
+
+/*
+Returns the number of nodes matching the path, using the same syntax as
+`Nodes.Select`. Equivalent to the length of the output of `Nodes.Select`. The
+path is evaluated the same way, which finds all matching nodes, but the output
+slice is not allocated.
+*/
+func (self Nodes) Count(path string, namespaces map[string]string) (int, error) {
+	matches, err := self.selectPath(path, namespaces)
+	return len(matches), err
+}
+
+func (self Nodes) selectPath(path string, namespaces map[string]string) ([]*selNode, error) {
+	steps, err := parseXPath(path, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	root := newSelTree(self)
+
+	var parents map[*selNode]*selNode
+	for _, step := range steps {
+		if step.axis == axisAncestor || step.axis == axisAncestorOrSelf {
+			parents = root.parents(map[*selNode]*selNode{})
+			break
+		}
+	}
+
+	ctx := []*selNode{root}
+	for _, step := range steps {
+		if step.attr {
+			return step.evalAttrs(ctx), nil
+		}
+		ctx = step.eval(ctx, parents)
+	}
+	return ctx, nil
+}
+
+type xpathAxis byte
+
+const (
+	axisChild xpathAxis = iota
+	axisDescendant
+	axisAncestor
+	axisAncestorOrSelf
+)
+
+type xpathStep struct {
+	axis xpathAxis
+	xpathName
+	attr  bool
+	preds []xpathPred
+}
+
+/*
+Name test of a step or an attribute predicate. An empty `local` matches any
+name.
+*/
+type xpathName struct {
+	anySpace bool
+	space    string
+	local    string
+}
+
+/*
+Predicate such as `[2]`, `[@id]` or `[@id='one']`. Positions are 1-based;
+zero means this is an attribute predicate.
+*/
+type xpathPred struct {
+	pos      int
+	attr     xpathName
+	value    string
+	hasValue bool
+}
+
+func parseXPath(path string, namespaces map[string]string) ([]xpathStep, error) {
+	if path == "" {
+		return nil, fmt.Errorf(`invalid XPath: empty path`)
+	}
+
+	var out []xpathStep
+	rest := strings.TrimPrefix(path, `/`)
+
+	for {
+		var step xpathStep
+		if strings.HasPrefix(rest, `/`) {
+			step.axis = axisDescendant
+			rest = rest[1:]
+		}
+
+		ind := xpathStepLen(rest)
+		test := rest[:ind]
+		rest = rest[ind:]
+
+		err := step.parseTest(test, namespaces)
+		if err == nil && step.attr && rest != "" {
+			err = fmt.Errorf(`attribute step %q must be last`, test)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`invalid XPath %q: %w`, path, err)
+		}
+		out = append(out, step)
+
+		if rest == "" {
+			return out, nil
+		}
+		rest = rest[1:]
+	}
+}
+
+// Length of the step at the start of the path, up to the next unquoted "/"
+// outside of predicates.
+func xpathStepLen(path string) int {
+	var quote byte
+	depth := 0
+
+	for ind := 0; ind < len(path); ind++ {
+		char := path[ind]
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '[':
+			depth++
+		case char == ']':
+			depth--
+		case char == '/' && depth <= 0:
+			return ind
+		}
+	}
+	return len(path)
+}
+
+func (self *xpathStep) parseTest(test string, namespaces map[string]string) error {
+	if test == "" {
+		return fmt.Errorf(`empty step`)
+	}
+
+	for _, axis := range []struct {
+		prefix string
+		axis   xpathAxis
+	}{
+		{`ancestor::`, axisAncestor},
+		{`ancestor-or-self::`, axisAncestorOrSelf},
+	} {
+		if !strings.HasPrefix(test, axis.prefix) {
+			continue
+		}
+		if self.axis == axisDescendant {
+			return fmt.Errorf(`unsupported axis %q after "//"`, axis.prefix)
+		}
+		self.axis = axis.axis
+		test = test[len(axis.prefix):]
+		break
+	}
+
+	name := test
+	if ind := strings.IndexByte(test, '['); ind >= 0 {
+		name = test[:ind]
+		err := self.parsePreds(test[ind:], namespaces)
+		if err != nil {
+			return err
+		}
+	}
+
+	if strings.HasPrefix(name, `@`) {
+		if self.axis == axisAncestor || self.axis == axisAncestorOrSelf {
+			return fmt.Errorf(`unsupported axis for attribute %q`, name)
+		}
+		if len(self.preds) > 0 {
+			return fmt.Errorf(`unsupported predicate for attribute %q`, name)
+		}
+		self.attr = true
+		name = name[1:]
+	}
+
+	var err error
+	self.xpathName, err = parseXPathName(name, namespaces)
+	return err
+}
+
+func (self *xpathStep) parsePreds(src string, namespaces map[string]string) error {
+	for src != "" {
+		if src[0] != '[' {
+			return fmt.Errorf(`unexpected %q after predicate`, src)
+		}
+		end := xpathPredLen(src)
+		if end < 0 {
+			return fmt.Errorf(`unterminated predicate %q`, src)
+		}
+
+		pred, err := parseXPathPred(strings.Trim(src[1:end-1], whitespace), namespaces)
+		if err != nil {
+			return err
+		}
+		self.preds = append(self.preds, pred)
+		src = src[end:]
+	}
+	return nil
+}
+
+// Length of the predicate at the start of the input, including brackets, or
+// -1 if unterminated.
+func xpathPredLen(src string) int {
+	var quote byte
+	for ind := 1; ind < len(src); ind++ {
+		char := src[ind]
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == ']':
+			return ind + 1
+		}
+	}
+	return -1
+}
+
+func parseXPathPred(src string, namespaces map[string]string) (xpathPred, error) {
+	var out xpathPred
+
+	if !strings.HasPrefix(src, `@`) {
+		pos, err := strconv.Atoi(src)
+		if err != nil || pos < 1 {
+			return out, fmt.Errorf(`unsupported predicate [%s]`, src)
+		}
+		out.pos = pos
+		return out, nil
+	}
+
+	name := src[1:]
+	if ind := strings.IndexByte(name, '='); ind >= 0 {
+		value := strings.Trim(name[ind+1:], whitespace)
+		name = strings.Trim(name[:ind], whitespace)
+
+		if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+			return out, fmt.Errorf(`expected quoted value in predicate [%s]`, src)
+		}
+		out.value, out.hasValue = value[1:len(value)-1], true
+	}
+
+	var err error
+	out.attr, err = parseXPathName(name, namespaces)
+	return out, err
+}
+
+func parseXPathName(test string, namespaces map[string]string) (xpathName, error) {
+	var out xpathName
+
+	prefix, local := "", test
+	if ind := strings.IndexByte(test, ':'); ind >= 0 {
+		prefix, local = test[:ind], test[ind+1:]
+	}
+
+	if local != `*` && !isName(local) {
+		return out, fmt.Errorf(`invalid name %q`, local)
+	}
+	if local != `*` {
+		out.local = local
+	}
+
+	if prefix == "" {
+		out.anySpace = true
+		return out, nil
+	}
+
+	uri, ok := namespaces[prefix]
+	if !ok {
+		return out, fmt.Errorf(`unbound namespace prefix %q`, prefix)
+	}
+	out.space = uri
+	return out, nil
+}
+
+func (self xpathStep) eval(ctx []*selNode, parents map[*selNode]*selNode) []*selNode {
+	var out []*selNode
+	seen := map[*selNode]bool{}
+
+	visit := func(nodes []*selNode) {
+		for _, node := range self.filter(nodes) {
+			if !seen[node] {
+				seen[node] = true
+				out = append(out, node)
+			}
+		}
+	}
+
+	for _, node := range ctx {
+		switch self.axis {
+		case axisDescendant:
+			// Predicates apply to the children of each descendant, as in
+			// "descendant-or-self::node()/child::name[pred]".
+			visit(node.kids)
+			node.eachDescendant(func(node *selNode) { visit(node.kids) })
+
+		case axisAncestorOrSelf:
+			visit(append([]*selNode{node}, ancestors(node, parents)...))
+
+		case axisAncestor:
+			visit(ancestors(node, parents))
+
+		default:
+			visit(node.kids)
+		}
+	}
+
+	sort.Slice(out, func(a, b int) bool { return out[a].order < out[b].order })
+	return out
+}
+
+/*
+Evaluates the final attribute step, such as `@id` or `//@id`, returning the
+values of the matching attributes as `Text`, in document order.
+*/
+func (self xpathStep) evalAttrs(ctx []*selNode) []*selNode {
+	var elems []*selNode
+	seen := map[*selNode]bool{}
+
+	visit := func(node *selNode) {
+		if node.elem != nil && !seen[node] {
+			seen[node] = true
+			elems = append(elems, node)
+		}
+	}
+
+	for _, node := range ctx {
+		visit(node)
+		if self.axis == axisDescendant {
+			node.eachDescendant(visit)
+		}
+	}
+	sort.Slice(elems, func(a, b int) bool { return elems[a].order < elems[b].order })
+
+	var out []*selNode
+	for _, node := range elems {
+		for _, attr := range node.elem.Attrs {
+			if self.xpathName.match(attr.Name) {
+				out = append(out, &selNode{node: Text(attr.Value), order: node.order})
+			}
+		}
+	}
+	return out
+}
+
+// Ancestors of the node, nearest first, which is their order for positional
+// predicates, excluding the virtual root.
+func ancestors(node *selNode, parents map[*selNode]*selNode) []*selNode {
+	var out []*selNode
+	for parent := parents[node]; parent != nil; parent = parents[parent] {
+		out = append(out, parent)
+	}
+	return out
+}
+
+// Returns the nodes matching the name test and predicates, in the given order.
+func (self xpathStep) filter(nodes []*selNode) []*selNode {
+	var out []*selNode
+	for _, node := range nodes {
+		if self.match(node) {
+			out = append(out, node)
+		}
+	}
+
+	for _, pred := range self.preds {
+		if pred.pos > 0 {
+			if pred.pos > len(out) {
+				return nil
+			}
+			out = out[pred.pos-1 : pred.pos]
+			continue
+		}
+
+		var next []*selNode
+		for _, node := range out {
+			if pred.match(node.elem) {
+				next = append(next, node)
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+func (self xpathStep) match(node *selNode) bool {
+	return node.elem != nil && self.xpathName.match(node.elem.Name)
+}
+
+func (self xpathName) match(name Name) bool {
+	return (self.local == "" || self.local == name.Local) &&
+		(self.anySpace || self.space == name.Space)
+}
+
+func (self xpathPred) match(elem *Elem) bool {
+	for _, attr := range elem.Attrs {
+		if self.attr.match(attr.Name) && (!self.hasValue || attr.Value == self.value) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Transient tree used for selection, which gives each element a stable identity
+and document order.
+*/
+type selNode struct {
+	node  Node
+	elem  *Elem
+	kids  []*selNode
+	order int
+}
+
+// Builds a selection tree with a virtual root containing the given nodes.
+func newSelTree(nodes Nodes) *selNode {
+	order := 0
+	root := &selNode{}
+	root.kids = newSelNodes(nodes, &order)
+	return root
+}
+
+func newSelNodes(nodes Nodes, order *int) []*selNode {
+	out := make([]*selNode, 0, len(nodes))
+	for _, node := range nodes {
+		*order++
+		sel := &selNode{node: node, order: *order}
+		sel.elem, _ = nodeElem(node)
+		if sel.elem != nil {
+			sel.kids = newSelNodes(sel.elem.Nodes, order)
+		}
+		out = append(out, sel)
+	}
+	return out
+}
+
+func (self *selNode) eachDescendant(fn func(*selNode)) {
+	for _, child := range self.kids {
+		fn(child)
+		child.eachDescendant(fn)
+	}
+}
+
+// Adds a mapping from each descendant to its parent.
+func (self *selNode) parents(out map[*selNode]*selNode) map[*selNode]*selNode {
+	for _, child := range self.kids {
+		out[child] = self
+		child.parents(out)
+	}
+	return out
+}
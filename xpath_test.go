@@ -0,0 +1,162 @@
+package xt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	test := func(path string, expected ...string) {
+		t.Helper()
+
+		out, err := expectedSimple.Select(path, nil)
+		require.NoError(t, err)
+
+		var names []string
+		for _, node := range out {
+			names = append(names, node.(Elem).Name.Local)
+		}
+		require.Equal(t, expected, names)
+	}
+
+	test(`/one`, `one`)
+	test(`one`, `one`)
+	test(`/one/six`, `six`)
+	test(`/one/six/nine`, `nine`)
+	test(`//nine`, `nine`)
+	test(`/one//nine`, `nine`)
+	test(`//*`, `one`, `six`, `nine`)
+	test(`/one/*`, `six`)
+	test(`/six`)
+	test(`//missing`)
+}
+
+func TestSelectNamespaces(t *testing.T) {
+	ns := map[string]string{`o`: `ns_outer`, `i`: `ns_inner`, `x`: `ns_missing`}
+
+	out, err := expectedNsAliased.Select(`//o:*`, ns)
+	require.NoError(t, err)
+	require.Equal(t, Nodes{expectedNsAliased[2], expectedNsAliased[2].(Elem).Nodes[1]}, out)
+
+	out, err = expectedNsAliased.Select(`/o:one/i:five`, ns)
+	require.NoError(t, err)
+	require.Equal(t, Nodes{expectedNsAliased[2].(Elem).Nodes[3]}, out)
+
+	out, err = expectedNsAliased.Select(`//x:five`, ns)
+	require.NoError(t, err)
+	require.Empty(t, out)
+
+	out, err = expectedNsAliased.Select(`//five`, nil)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+
+	_, err = expectedNsAliased.Select(`//atom:five`, ns)
+	require.EqualError(t, err, `invalid XPath "//atom:five": unbound namespace prefix "atom"`)
+}
+
+func TestSelectInvalid(t *testing.T) {
+	for _, path := range []string{``, `/`, `one//`, `one/`, `one/<two>`, `///one`} {
+		_, err := expectedSimple.Select(path, nil)
+		require.Error(t, err, path)
+	}
+}
+
+func TestCount(t *testing.T) {
+	doc := benchDoc(3)
+
+	count, err := doc.Count(`//item`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	count, err = doc.Count(`/root/item/value`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	count, err = doc.Count(`//missing`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	_, err = doc.Count(`/`, nil)
+	require.Error(t, err)
+}
+
+func TestSelectAncestors(t *testing.T) {
+	test := func(path string, expected ...string) {
+		t.Helper()
+
+		out, err := expectedSimple.Select(path, nil)
+		require.NoError(t, err)
+
+		var names []string
+		for _, node := range out {
+			names = append(names, node.(Elem).Name.Local)
+		}
+		require.Equal(t, expected, names)
+	}
+
+	test(`//nine/ancestor::one`, `one`)
+	test(`//nine/ancestor::*`, `one`, `six`)
+	test(`//nine/ancestor-or-self::*`, `one`, `six`, `nine`)
+	test(`/one/ancestor::*`)
+	test(`/one/ancestor-or-self::one`, `one`)
+	test(`//nine/ancestor::six/nine`, `nine`)
+	test(`//*/ancestor::one`, `one`)
+
+	_, err := expectedSimple.Select(`//ancestor::one`, nil)
+	require.EqualError(t, err, `invalid XPath "//ancestor::one": unsupported axis "ancestor::" after "//"`)
+}
+
+func TestXPath(t *testing.T) {
+	doc, err := Parser{}.Parse([]byte(`<root id="r">
+  <item id="1" kind="a"><name>one</name></item>
+  <item id="2" kind="b"><name>two</name><item id="3" kind="a/b"/></item>
+  <other id="4"/>
+</root>`))
+	require.NoError(t, err)
+
+	ids := func(expr string) []string {
+		t.Helper()
+
+		out, err := doc.XPath(expr)
+		require.NoError(t, err)
+
+		var vals []string
+		for _, node := range out {
+			switch node := node.(type) {
+			case Text:
+				vals = append(vals, string(node))
+			case Elem:
+				val, _ := node.attrValue(Name{Local: `id`})
+				vals = append(vals, val)
+			}
+		}
+		return vals
+	}
+
+	require.Equal(t, []string{`1`, `2`}, ids(`/root/item`))
+	require.Equal(t, []string{`2`}, ids(`/root/item[2]`))
+	require.Nil(t, ids(`/root/item[3]`))
+	require.Equal(t, []string{`1`, `3`}, ids(`//item[1]`))
+	require.Equal(t, []string{`1`, `2`, `3`}, ids(`//item[@kind]`))
+	require.Equal(t, []string{`3`}, ids(`//item[@kind="a/b"]`))
+	require.Equal(t, []string{`2`}, ids(`//item[@kind][2]`))
+	require.Equal(t, []string{`2`}, ids(`//item[ @kind = 'b' ][1]`))
+	require.Equal(t, []string{`2`}, ids(`//item[@kind='a/b']/ancestor::item[1]`))
+	require.Equal(t, []string{`r`}, ids(`/root/@id`))
+	require.Equal(t, []string{`2`, `b`}, ids(`/root/item[2]/@*`))
+	require.Equal(t, []string{`r`, `1`, `2`, `3`, `4`}, ids(`//@id`))
+	require.Equal(t, []string{`1`, `2`, `3`}, ids(`//item/@id`))
+	require.Nil(t, ids(`//name/@id`))
+
+	count, err := doc.Count(`//@kind`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	for _, expr := range []string{`//item[0]`, `//item[x]`, `//item[@kind=a]`, `//item[@kind`, `/root/@id/item`, `//@id[1]`, `//item[1]x`, `//ancestor::@id`} {
+		_, err := doc.XPath(expr)
+		require.Error(t, err, expr)
+	}
+	_, err = doc.XPath(`//item[@kind=a]`)
+	require.EqualError(t, err, `invalid XPath "//item[@kind=a]": expected quoted value in predicate [@kind=a]`)
+}
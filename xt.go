@@ -29,6 +29,9 @@ const (
 	TypeDecl    = "decl"
 	TypeComment = "comment"
 	TypeText    = "text"
+	TypeCData   = "cdata"
+	TypeEntity  = "entity"
+	TypeDoctype = "doctype"
 	TypeElem    = "elem"
 )
 
@@ -70,6 +73,9 @@ Represents any XML node. One of:
 	* Decl
 	* Comment
 	* Text
+	* CData
+	* EntityRef
+	* Doctype
 	* Elem
 	* Nodes
 
@@ -149,6 +155,44 @@ func (self Decl) MarshalJSON() ([]byte, error) {
 	return jsonMarshalContent(TypeDecl, string(self))
 }
 
+/*
+Represents a document type declaration, such as `<!DOCTYPE html>`, with its
+parts parsed out. The internal subset is kept as raw text; see
+`Doctype.Entities`. Encodes back into the equivalent `Decl`.
+
+XML <-> JSON:
+
+	<!DOCTYPE one PUBLIC "two" "three" [<!ENTITY four "five">]>
+	<->
+	{"type": "doctype", "name": "one", "publicId": "two", "systemId": "three", "internalSubset": "<!ENTITY four \"five\">"}
+
+Only decoded by `Parser` with `DecodeDoctype`. Otherwise, doctypes are decoded
+as `Decl`, which can be converted via `Decl.Doctype`.
+*/
+type Doctype struct {
+	Name           string `json:"name,omitempty"`
+	PublicID       string `json:"publicId,omitempty"`
+	SystemID       string `json:"systemId,omitempty"`
+	InternalSubset string `json:"internalSubset,omitempty"`
+}
+
+var _ = xml.Marshaler(Doctype{})
+
+func (self Doctype) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	if self.Name == "" {
+		return fmt.Errorf(`can't encode XML doctype with empty name`)
+	}
+	return enc.EncodeToken(xml.Directive(self.Decl()))
+}
+
+func (self Doctype) MarshalJSON() ([]byte, error) {
+	type inner Doctype
+	return json.Marshal(struct {
+		typeHead
+		inner
+	}{typeHead{TypeDoctype}, inner(self)})
+}
+
 /*
 Represents an XML comment. Variant of `xml.Comment` with reversible
 decoding/encoding.
@@ -205,13 +249,93 @@ func (self Text) MarshalJSON() ([]byte, error) {
 	return jsonMarshalContent(TypeText, string(self))
 }
 
+/*
+Represents an XML CDATA section. Encodes as a literal `<![CDATA[...]]>` block
+via `MarshalOptions`. Via `encoding/xml`, which can't write CDATA sections
+outside of struct fields, encodes as regular escaped text with the same
+meaning.
+
+XML <-> JSON:
+
+	<![CDATA[some <content>]]>
+	<->
+	{"type": "cdata", "content": "some <content>"}
+
+`encoding/xml` doesn't distinguish CDATA sections from regular text in its
+tokens, so `Nodes.Decode`, `DecodeToken` and `DecodeSafe` decode them as
+`Text`. To preserve such nodes, use `Parser` or `ParseFragment`, which also
+backs `Elem.SetInnerXML` and `Nodes.ReplaceOuterXML`.
+*/
+type CData string
+
+var _ = xml.Marshaler(CData(""))
+
+func (self CData) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return enc.EncodeToken(xml.CharData(self))
+}
+
+func (self *CData) UnmarshalJSON(input []byte) error {
+	return jsonUnmarshalContent(input, (*string)(self))
+}
+
+func (self CData) MarshalJSON() ([]byte, error) {
+	return jsonMarshalContent(TypeCData, string(self))
+}
+
+/*
+Represents a reference to a general entity in text, such as `&copy;`, by the
+entity's name. Since this package doesn't read DTDs, the entity's value is
+unknown, and the reference is preserved as-is. Encodes as a literal reference
+via `MarshalOptions`; `encoding/xml` can't write entity references, so encoding
+via `xml.Marshal` fails.
+
+XML <-> JSON:
+
+	&copy;
+	<->
+	{"type": "entity", "name": "copy"}
+
+Only decoded by `Parser` with `PreserveEntityRefs`.
+*/
+type EntityRef string
+
+var _ = xml.Marshaler(EntityRef(""))
+
+func (self EntityRef) MarshalXML(*xml.Encoder, xml.StartElement) error {
+	return fmt.Errorf(`can't encode XML entity reference &%s; via encoding/xml; use MarshalOptions`, string(self))
+}
+
+func (self *EntityRef) UnmarshalJSON(input []byte) error {
+	return json.Unmarshal(input, &struct {
+		Name *string `json:"name,omitempty"`
+	}{(*string)(self)})
+}
+
+func (self EntityRef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		typeHead
+		Name string `json:"name,omitempty"`
+	}{typeHead{TypeEntity}, string(self)})
+}
+
 /*
 Represents an arbitrary XML element with minimal information loss.
+
+`Prefix` is the namespace prefix to use when encoding, such as "one" in
+`<one:two>`. Decoding leaves it empty; see `Nodes.ResolveNamespaces`. It's
+ignored when `Name.Space` is empty, and doesn't affect the meaning of the
+element or comparisons via `Equal`.
+
+`RawAttrs` optionally holds the source text of each attribute value, between
+the quotes, parallel to `Attrs`. See `Parser.RawAttrs`. Like `Prefix`, it
+doesn't affect comparisons via `Equal`.
 */
 type Elem struct {
-	Name  Name   `json:"name,omitempty"`
-	Attrs []Attr `json:"attrs,omitempty"`
-	Nodes Nodes  `json:"nodes,omitempty"`
+	Name     Name     `json:"name,omitempty"`
+	Prefix   string   `json:"prefix,omitempty"`
+	Attrs    []Attr   `json:"attrs,omitempty"`
+	RawAttrs []string `json:"rawAttrs,omitempty"`
+	Nodes    Nodes    `json:"nodes,omitempty"`
 }
 
 var _ = xml.Unmarshaler((*Elem)(nil))
@@ -223,10 +347,10 @@ func (self *Elem) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
 	for {
 		tok, err := dec.Token()
 		if errors.Is(err, io.EOF) {
-			return nil
+			err = io.ErrUnexpectedEOF
 		}
 		if err != nil {
-			return err
+			return ElementStackError{Stack: []Name{self.Name}, Err: err}
 		}
 
 		_, ok := tok.(xml.EndElement)
@@ -236,7 +360,7 @@ func (self *Elem) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
 
 		err = self.Nodes.DecodeToken(dec, tok)
 		if err != nil {
-			return err
+			return withParentElem(self.Name, err)
 		}
 	}
 }
@@ -266,7 +390,16 @@ func (self Elem) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
 		self.Name.Space = ""
 	}
 
-	start := xml.StartElement{Name: xml.Name(self.Name), Attr: attrsTo(self.Attrs)}
+	/**
+	`xml.Encoder` writes unqualified names verbatim, which allows to use the
+	prefix. The prefix must be declared by this element or an ancestor, which
+	is guaranteed by `Nodes.ResolveNamespaces`.
+	*/
+	if self.Prefix != "" && self.Name.Space != "" {
+		self.Name = Name{Local: self.Prefix + `:` + self.Name.Local}
+	}
+
+	start := xml.StartElement{Name: xml.Name(self.Name), Attr: attrsToEncode(self.Attrs)}
 	err := enc.EncodeToken(start)
 	if err != nil {
 		return err
@@ -405,6 +538,34 @@ func (self Nodes) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
 	return nil
 }
 
+/*
+Encodes the nodes via `xml.Encoder` with the given indentation, as configured
+by `(*xml.Encoder).Indent`, and flushes the output.
+
+Indentation is added around elements regardless of existing whitespace, so it's
+mainly useful for nodes without whitespace-only text, such as nodes built in
+code. For decoded documents, the original whitespace text is written in
+addition to the indentation, producing blank lines and doubled indentation.
+Strip such text first via `Nodes.StripInsignificantWhitespace`, or use
+`MarshalOptions.Indent`, which replaces it.
+*/
+func (self Nodes) EncodeIndent(out io.Writer, prefix, indent string) error {
+	enc := xml.NewEncoder(out)
+	enc.Indent(prefix, indent)
+
+	err := enc.Encode(self)
+	if err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+var _ = json.Marshaler(Nodes(nil))
+
+func (self Nodes) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]Node(self))
+}
+
 var _ = json.Unmarshaler((*Nodes)(nil))
 
 func (self *Nodes) UnmarshalJSON(input []byte) error {
@@ -470,6 +631,21 @@ func (self *nodeDecoder) UnmarshalJSON(input []byte) error {
 		err = json.Unmarshal(input, &val)
 		self.Node = val
 
+	case TypeCData:
+		var val CData
+		err = json.Unmarshal(input, &val)
+		self.Node = val
+
+	case TypeEntity:
+		var val EntityRef
+		err = json.Unmarshal(input, &val)
+		self.Node = val
+
+	case TypeDoctype:
+		var val Doctype
+		err = json.Unmarshal(input, &val)
+		self.Node = val
+
 	case TypeElem:
 		var val Elem
 		err = json.Unmarshal(input, &val)
@@ -199,3 +199,24 @@ func read(t testing.TB, path string) []byte {
 	require.NoError(t, err)
 	return out
 }
+
+func TestEncodeIndent(t *testing.T) {
+	doc := Nodes{Elem{
+		Name:  Name{Local: `one`},
+		Attrs: []Attr{{Name: Name{Local: `two`}, Value: `three`}},
+		Nodes: Nodes{Elem{Name: Name{Local: `four`}, Nodes: Nodes{Text(`five`)}}, Elem{Name: Name{Local: `six`}}},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, doc.EncodeIndent(&buf, ``, `  `))
+	require.Equal(t, `<one two="three">
+  <four>five</four>
+  <six></six>
+</one>`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, doc.EncodeIndent(&buf, `//`, "\t"))
+	require.Equal(t, "//<one two=\"three\">\n//\t<four>five</four>\n//\t<six></six>\n//</one>", buf.String())
+
+	require.Error(t, Nodes{Elem{}}.EncodeIndent(&buf, ``, `  `))
+}
@@ -0,0 +1,23 @@
+/*
+Support for parsing XML documents in encodings other than UTF-8, such as
+"windows-1251" or "iso-8859-1", via "golang.org/x/net/html/charset". This is a
+separate module so that the core "xt" module remains dependency-free.
+*/
+package xtcharset
+
+import (
+	"github.com/purelabio/xt"
+	"golang.org/x/net/html/charset"
+)
+
+/*
+Parses a document in any encoding supported by `charset.NewReaderLabel`, as
+declared by its XML declaration, into UTF-8 nodes. Shortcut for `xt.Parser`
+with `CharsetReader` set to `charset.NewReaderLabel`. The XML declaration is
+preserved as-is, including its "encoding", and thus does not match the
+encoding of the output of `xt.MarshalOptions.Marshal` and similar functions. Use
+`xt.Parser.CharsetReader` to combine this with other parser options.
+*/
+func ParseCharset(src []byte) (xt.Nodes, error) {
+	return xt.Parser{CharsetReader: charset.NewReaderLabel}.Parse(src)
+}
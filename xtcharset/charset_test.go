@@ -0,0 +1,26 @@
+package xtcharset
+
+import (
+	"testing"
+
+	"github.com/purelabio/xt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCharset(t *testing.T) {
+	src := []byte("<?xml version=\"1.0\" encoding=\"windows-1251\"?><one two=\"\xcf\xf0\xe8\">\xe2\xe5\xf2</one>")
+
+	nodes, err := ParseCharset(src)
+	require.NoError(t, err)
+	require.Equal(t, xt.Nodes{
+		xt.Pi{Target: `xml`, Content: `version="1.0" encoding="windows-1251"`},
+		xt.Elem{
+			Name:  xt.Name{Local: `one`},
+			Attrs: []xt.Attr{{Name: xt.Name{Local: `two`}, Value: "\u041f\u0440\u0438"}},
+			Nodes: xt.Nodes{xt.Text("\u0432\u0435\u0442")},
+		},
+	}, nodes)
+
+	_, err = ParseCharset([]byte(`<?xml version="1.0" encoding="unknown"?><one/>`))
+	require.Error(t, err)
+}
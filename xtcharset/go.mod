@@ -0,0 +1,14 @@
+module github.com/purelabio/xt/xtcharset
+
+go 1.16
+
+require (
+	github.com/purelabio/xt v0.0.0
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+)
+
+// This dependency is test-only.
+require github.com/stretchr/testify v1.7.0
+
+// Use the core package from this repository.
+replace github.com/purelabio/xt => ../
@@ -0,0 +1,147 @@
+/*
+Decoding of HTML documents and fragments into the node types of "xt", via the
+tokenizer of "golang.org/x/net/html". This is a separate module so that the
+core "xt" module remains dependency-free.
+*/
+package xthtml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/purelabio/xt"
+	"golang.org/x/net/html"
+)
+
+/*
+Parses HTML, which is often not well-formed XML, into nodes. Tolerates what
+`encoding/xml` rejects:
+
+	void elements without end tags, such as `<br>` or `<img src="x">`
+	self-closing tags, such as `<br/>`
+	unquoted and valueless attributes, such as `<input type=checkbox checked>`
+	named character references, such as `&nbsp;`, decoded into text
+	raw text in `<script>` and `<style>`
+	end tags which implicitly close unclosed descendants
+	stray end tags, which are ignored
+	elements left open at the end of input, which are closed
+
+Unlike a full HTML5 parser, this doesn't restructure the document, for example
+by inserting `<html>` or `<tbody>` or implicitly closing `<p>` and `<li>`; the
+nodes follow the input. Tag and attribute names are lowercased. The doctype
+becomes `xt.Decl`, such as "DOCTYPE html". The output consists of the regular
+node types, and round-trips through their JSON representation.
+*/
+func ParseHTML(src []byte) (xt.Nodes, error) {
+	var out builder
+	tokenizer := html.NewTokenizer(bytes.NewReader(src))
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			err := tokenizer.Err()
+			if errors.Is(err, io.EOF) {
+				for len(out.stack) > 0 {
+					out.close()
+				}
+				return out.nodes, nil
+			}
+			return nil, err
+
+		case html.TextToken:
+			out.append(xt.Text(tokenizer.Token().Data))
+
+		case html.CommentToken:
+			out.append(xt.Comment(tokenizer.Token().Data))
+
+		case html.DoctypeToken:
+			out.append(xt.Decl(`DOCTYPE ` + tokenizer.Token().Data))
+
+		case html.StartTagToken:
+			elem := elemFrom(tokenizer.Token())
+			if voidElems[elem.Name.Local] {
+				out.append(elem)
+			} else {
+				out.stack = append(out.stack, elem)
+			}
+
+		case html.SelfClosingTagToken:
+			out.append(elemFrom(tokenizer.Token()))
+
+		case html.EndTagToken:
+			out.end(tokenizer.Token().Data)
+		}
+	}
+}
+
+// Elements which never have content or end tags.
+var voidElems = map[string]bool{
+	`area`:   true,
+	`base`:   true,
+	`br`:     true,
+	`col`:    true,
+	`embed`:  true,
+	`hr`:     true,
+	`img`:    true,
+	`input`:  true,
+	`link`:   true,
+	`meta`:   true,
+	`param`:  true,
+	`source`: true,
+	`track`:  true,
+	`wbr`:    true,
+}
+
+func elemFrom(tok html.Token) xt.Elem {
+	out := xt.Elem{
+		Name:  xt.Name{Local: tok.Data},
+		Attrs: make([]xt.Attr, 0, len(tok.Attr)),
+	}
+	for _, attr := range tok.Attr {
+		out.Attrs = append(out.Attrs, xt.Attr{
+			Name:  xt.Name{Space: attr.Namespace, Local: attr.Key},
+			Value: attr.Val,
+		})
+	}
+	return out
+}
+
+/*
+Accumulates the top-level nodes and the stack of currently open elements,
+which are appended to their parents when closed.
+*/
+type builder struct {
+	nodes xt.Nodes
+	stack []xt.Elem
+}
+
+func (self *builder) append(node xt.Node) {
+	if len(self.stack) > 0 {
+		top := &self.stack[len(self.stack)-1]
+		top.Nodes = append(top.Nodes, node)
+	} else {
+		self.nodes = append(self.nodes, node)
+	}
+}
+
+func (self *builder) close() {
+	last := len(self.stack) - 1
+	elem := self.stack[last]
+	self.stack = self.stack[:last]
+	self.append(elem)
+}
+
+// Closes the innermost open element with the given name, along with any
+// elements opened inside it. Does nothing if there is no such element.
+func (self *builder) end(local string) {
+	for ind := len(self.stack) - 1; ind >= 0; ind-- {
+		if self.stack[ind].Name.Local != local {
+			continue
+		}
+		for len(self.stack) > ind {
+			self.close()
+		}
+		return
+	}
+}
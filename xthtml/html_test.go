@@ -0,0 +1,55 @@
+package xthtml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/purelabio/xt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHTML(t *testing.T) {
+	src := []byte(`<!DOCTYPE html>
+<div class=main><p>one<br>two &amp; three&nbsp;<img src="x" alt=''></p><input type=checkbox checked/><!-- four --><ul><li>five</ul></span><script>if (a < b) {}</script><p>six`)
+
+	nodes, err := ParseHTML(src)
+	require.NoError(t, err)
+
+	elem := func(local string, attrs []xt.Attr, nodes ...xt.Node) xt.Elem {
+		return xt.Elem{Name: xt.Name{Local: local}, Attrs: attrs, Nodes: nodes}
+	}
+	attr := func(local, value string) xt.Attr {
+		return xt.Attr{Name: xt.Name{Local: local}, Value: value}
+	}
+	none := []xt.Attr{}
+
+	require.Equal(t, xt.Nodes{
+		xt.Decl(`DOCTYPE html`),
+		xt.Text("\n"),
+		elem(`div`, []xt.Attr{attr(`class`, `main`)},
+			elem(`p`, none,
+				xt.Text(`one`),
+				elem(`br`, none),
+				xt.Text("two & three\u00a0"),
+				elem(`img`, []xt.Attr{attr(`src`, `x`), attr(`alt`, ``)}),
+			),
+			elem(`input`, []xt.Attr{attr(`type`, `checkbox`), attr(`checked`, ``)}),
+			xt.Comment(` four `),
+			elem(`ul`, none, elem(`li`, none, xt.Text(`five`))),
+			elem(`script`, none, xt.Text(`if (a < b) {}`)),
+			elem(`p`, none, xt.Text(`six`)),
+		),
+	}, nodes)
+
+	out, err := xml.Marshal(nodes)
+	require.NoError(t, err)
+	require.Equal(t, "<!DOCTYPE html>\n<div class=\"main\"><p>one<br></br>two &amp; three\u00a0<img src=\"x\" alt=\"\"></img></p><input type=\"checkbox\" checked=\"\"></input><!-- four --><ul><li>five</li></ul><script>if (a &lt; b) {}</script><p>six</p></div>", string(out))
+
+	out, err = json.Marshal(nodes)
+	require.NoError(t, err)
+
+	var decoded xt.Nodes
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.True(t, xt.Equal(nodes, decoded, xt.EqualOptions{}))
+}